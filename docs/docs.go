@@ -15,32 +15,55 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/billing-components": {
+        "/billing-aggregates": {
             "get": {
-                "description": "Returns all months for which billing information exists for the resolved user.",
+                "description": "Sums the latest billing information of every month from 'from' up to and including 'to'.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "billing-components"
+                    "billing-aggregates"
                 ],
-                "summary": "List available billing periods",
+                "summary": "Get aggregated billing details for a range of months",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Target user id (admin only)",
                         "name": "for_user",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Aggregate over all users (admin only)",
+                        "name": "all_users",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month (YYYY-MM)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month (YYYY-MM)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/model.BillingAggregate"
                         }
                     },
                     "400": {
@@ -49,6 +72,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -58,16 +87,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/billing-components/{year}/{month}": {
+        "/billing-aggregates/quarters/{year}/{quarter}": {
             "get": {
-                "description": "Returns billing information for a specific year and month for the resolved user.",
+                "description": "Sums the latest billing information of every month of the quarter.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "billing-components"
+                    "billing-aggregates"
                 ],
-                "summary": "Get billing details for month",
+                "summary": "Get aggregated billing details for a quarter",
                 "parameters": [
                     {
                         "type": "string",
@@ -75,6 +104,18 @@ const docTemplate = `{
                         "name": "for_user",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Aggregate over all users (admin only)",
+                        "name": "all_users",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start",
+                        "name": "currency",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Year",
@@ -84,8 +125,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Month (1-12)",
-                        "name": "month",
+                        "description": "Quarter (1-4)",
+                        "name": "quarter",
                         "in": "path",
                         "required": true
                     }
@@ -94,10 +135,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.BillingInformation"
-                            }
+                            "$ref": "#/definitions/model.BillingAggregate"
                         }
                     },
                     "400": {
@@ -106,6 +144,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -115,19 +159,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/doc": {
+        "/billing-aggregates/years/{year}": {
             "get": {
-                "description": "Returns the generated Swagger document for this service.",
+                "description": "Sums the latest billing information of every month of the year.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "documentation"
+                    "billing-aggregates"
+                ],
+                "summary": "Get aggregated billing details for a year",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user id (admin only)",
+                        "name": "for_user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Aggregate over all users (admin only)",
+                        "name": "all_users",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get OpenAPI document",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BillingAggregate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -140,63 +223,1265 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.BillingInformation": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "from": {
-                    "type": "string"
-                },
-                "to": {
-                    "type": "string"
-                },
-                "tree": {
-                    "$ref": "#/definitions/model.CostTree"
-                }
-            }
         },
-        "model.CostEntry": {
-            "type": "object",
-            "properties": {
-                "cpu": {
-                    "type": "number"
-                },
-                "ram": {
-                    "type": "number"
-                },
-                "requests": {
-                    "type": "number"
-                },
-                "storage": {
-                    "type": "number"
+        "/billing-changes/{year}/{month}": {
+            "get": {
+                "description": "Compares the snapshots of the month valid at 'since' and at 'until', the newest snapshot created at or before each instant, of every user.\nUsers whose tree hash differs or who only have a snapshot at one of the instants are listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-changes"
+                ],
+                "summary": "List changed bills of a month",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month (1-12)",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First instant (RFC3339)",
+                        "name": "since",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second instant (RFC3339), defaults to now",
+                        "name": "until",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BillingChangeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "model.CostTree": {
-            "type": "object",
-            "additionalProperties": {
-                "$ref": "#/definitions/model.CostWithChildren"
+        "/billing-components": {
+            "get": {
+                "description": "Returns the months for which billing information exists for the resolved user, sorted by month,\nwith the total of the latest snapshot and the number of snapshots of each month.\nThe number of months matching from and to is returned in the X-Total-Count header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-components"
+                ],
+                "summary": "List available billing periods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user id (admin only)",
+                        "name": "for_user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First month (YYYY-MM)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month (YYYY-MM), included",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of months (1-1000), all months if not set",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of months to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert the totals into this currency (ISO 4217), with the exchange rate valid at the start of each month",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BillingMonthSummary"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "number of matching months"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
             }
         },
-        "model.CostWithChildren": {
-            "type": "object",
-            "properties": {
-                "children": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "$ref": "#/definitions/model.CostWithChildren"
-                    }
-                },
-                "estimation_month": {
-                    "$ref": "#/definitions/model.CostEntry"
-                },
-                "month": {
-                    "$ref": "#/definitions/model.CostEntry"
+        "/billing-components/{year}/{month}": {
+            "get": {
+                "description": "Returns billing information for a specific year and month for the resolved user.\nThe response carries an ETag derived from the tree hashes. Requests with a matching If-None-Match header are answered with 304.\nThe trees can be reduced to the subtree at 'path', given as one parameter per node name starting at the top level,\nand cut after 'depth' levels counted from the selected node. Costs of removed children stay included in their parent.\nSnapshots without a node at 'path' contain an empty tree.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-components"
+                ],
+                "summary": "Get billing details for month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user id (admin only)",
+                        "name": "for_user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Month (1-12)",
+                        "name": "month",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Node names from the top level down to the selected node",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of levels kept, 0 keeps all",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of the month",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BillingInformation"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "entity tag of the snapshots"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-gaps": {
+            "get": {
+                "description": "Lists every month from 'from' up to and including 'to' without billing information of a keycloak user.\nMonths ending before the creation of the user are not reported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-gaps"
+                ],
+                "summary": "List billing gaps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First month (YYYY-MM)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month (YYYY-MM)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only check this user",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GapReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-gaps/backfill": {
+            "post": {
+                "description": "Finds the gaps like GET /billing-gaps and stores the billing information of every gap in the background.\nAnswers with 409 if a billing job is already running.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-gaps"
+                ],
+                "summary": "Backfill billing gaps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First month (YYYY-MM)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month (YYYY-MM)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only check this user",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.GapReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-information": {
+            "get": {
+                "description": "Streams the latest snapshot of every user and period starting between from and to, both included, as newline delimited json.\nEvery line is a model.UserBillingInformation. The snapshots are read one by one from the database.\nIf reading fails after the first line was sent, the last line is an object with an 'error' field.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "billing-information"
+                ],
+                "summary": "Export billing information",
+                "parameters": [
+                    {
+                        "enum": [
+                            "month",
+                            "quarter",
+                            "week",
+                            "custom"
+                        ],
+                        "type": "string",
+                        "description": "Period type, defaults to month",
+                        "name": "period_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only export this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of each period",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserBillingInformation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-periods/{type}": {
+            "get": {
+                "description": "Returns the start of every period of the given type for which billing information exists for the resolved user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-periods"
+                ],
+                "summary": "List available billing periods of a type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user id (admin only)",
+                        "name": "for_user",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "month",
+                            "quarter",
+                            "week",
+                            "custom"
+                        ],
+                        "type": "string",
+                        "description": "Period type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-periods/{type}/{start}": {
+            "get": {
+                "description": "Returns billing information for the period of the given type starting on the given day (in the billing time zone) for the resolved user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-periods"
+                ],
+                "summary": "Get billing details for period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Target user id (admin only)",
+                        "name": "for_user",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "month",
+                            "quarter",
+                            "week",
+                            "custom"
+                        ],
+                        "type": "string",
+                        "description": "Period type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day of the period (YYYY-MM-DD)",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of the period",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BillingInformation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/billing-runs": {
+            "post": {
+                "description": "Runs the billing job for the requested periods, or for the periods configured for the job if from and to are empty. Ranges of more than 260 periods are rejected with 400.\nA dry run compares the fetched trees with the latest stored snapshots and returns the report without storing anything.\nOther runs are started in the background and answered with the periods to be stored, or with 409 if a billing job is already running.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing-runs"
+                ],
+                "summary": "Trigger a billing run",
+                "parameters": [
+                    {
+                        "description": "Billing run",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BillingRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.DryRunReport"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Period"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/doc": {
+            "get": {
+                "description": "Returns the generated Swagger document for this service.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documentation"
+                ],
+                "summary": "Get OpenAPI document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rates": {
+            "get": {
+                "description": "Returns the exchange rates used by the currency parameter of the read endpoints, sorted by currencies and validity.\nA rate is valid from its valid_from until the valid_from of the next rate of the same currencies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "List exchange rates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ExchangeRate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Stores an exchange rate, replacing the rate of the same currencies with the same valid_from.\nAmounts in 'from' are multiplied with 'rate' to get amounts in 'to'. The inverse direction is derived if no rate for it is stored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Set exchange rate",
+                "parameters": [
+                    {
+                        "description": "Exchange rate",
+                        "name": "rate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ExchangeRate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rates/{from}/{to}/{valid_from}": {
+            "delete": {
+                "description": "Removes the exchange rate of the currencies with the given valid_from.",
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Remove exchange rate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source currency (ISO 4217)",
+                        "name": "from",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Target currency (ISO 4217)",
+                        "name": "to",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of validity (RFC 3339)",
+                        "name": "valid_from",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Reports that the process is able to handle requests.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Checks the database and, if configured, the cost calculator and keycloak. Reports the state of every dependency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReport"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.BillingAggregate": {
+            "type": "object",
+            "properties": {
+                "computed_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the amounts in Tree",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "tree": {
+                    "$ref": "#/definitions/model.CostTree"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BillingChange": {
+            "type": "object",
+            "properties": {
+                "new": {
+                    "$ref": "#/definitions/model.Snapshot"
+                },
+                "old": {
+                    "$ref": "#/definitions/model.Snapshot"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BillingChangeReport": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BillingChange"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BillingGap": {
+            "type": "object",
+            "properties": {
+                "period": {
+                    "$ref": "#/definitions/model.Period"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BillingInformation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the amounts in Tree",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "TreeHash of Tree",
+                    "type": "string"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "run_id": {
+                    "description": "id of the run which stored the snapshot, empty for snapshots stored before run ids were introduced",
+                    "type": "string"
+                },
+                "time_zone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "tree": {
+                    "$ref": "#/definitions/model.CostTree"
+                }
+            }
+        },
+        "model.BillingMonthSummary": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217 code of Total",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "latest_created_at": {
+                    "type": "string"
+                },
+                "month": {
+                    "description": "YYYY-MM in the billing time zone",
+                    "type": "string"
+                },
+                "snapshots": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.BillingRunRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "from": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "run_id": {
+                    "description": "retrying a run with the same id replaces its snapshots instead of adding new ones",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CostEntry": {
+            "type": "object",
+            "properties": {
+                "cpu": {
+                    "type": "number"
+                },
+                "ram": {
+                    "type": "number"
+                },
+                "requests": {
+                    "type": "number"
+                },
+                "storage": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.CostTree": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/model.CostWithChildren"
+            }
+        },
+        "model.CostWithChildren": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.CostWithChildren"
+                    }
+                },
+                "estimation_month": {
+                    "$ref": "#/definitions/model.CostEntry"
+                },
+                "month": {
+                    "$ref": "#/definitions/model.CostEntry"
+                }
+            }
+        },
+        "model.DependencyHealth": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HealthStatus"
+                }
+            }
+        },
+        "model.DryRunEntry": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "type": "number"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "new_total": {
+                    "type": "number"
+                },
+                "old_total": {
+                    "type": "number"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "stored_at": {
+                    "description": "creation time of the compared snapshot, nil if nothing is stored yet",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DryRunReport": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DryRunEntry"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.GapReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "gaps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BillingGap"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.HealthReport": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.DependencyHealth"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/model.HealthStatus"
+                }
+            }
+        },
+        "model.HealthStatus": {
+            "type": "string",
+            "enum": [
+                "up",
+                "down"
+            ],
+            "x-enum-varnames": [
+                "HealthStatusUp",
+                "HealthStatusDown"
+            ]
+        },
+        "model.Period": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                }
+            }
+        },
+        "model.PeriodType": {
+            "type": "string",
+            "enum": [
+                "month",
+                "quarter",
+                "week",
+                "custom"
+            ],
+            "x-enum-varnames": [
+                "PeriodTypeMonth",
+                "PeriodTypeQuarter",
+                "PeriodTypeWeek",
+                "PeriodTypeCustom"
+            ]
+        },
+        "model.Snapshot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "run_id": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.UserBillingInformation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of the amounts in Tree",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "TreeHash of Tree",
+                    "type": "string"
+                },
+                "period_type": {
+                    "$ref": "#/definitions/model.PeriodType"
+                },
+                "run_id": {
+                    "description": "id of the run which stored the snapshot, empty for snapshots stored before run ids were introduced",
+                    "type": "string"
+                },
+                "time_zone": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "tree": {
+                    "$ref": "#/definitions/model.CostTree"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         }
@@ -18,7 +18,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -26,6 +28,7 @@ import (
 	"time"
 
 	"github.com/SENERGY-Platform/billing/pkg"
+	"github.com/SENERGY-Platform/billing/pkg/cli"
 	"github.com/SENERGY-Platform/billing/pkg/configuration"
 	_log "github.com/SENERGY-Platform/billing/pkg/log"
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
@@ -33,14 +36,41 @@ import (
 
 func main() {
 	configLocation := flag.String("config", "config.json", "configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [-config config.json] [command] [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+		cli.Usage(flag.CommandLine.Output())
+	}
 	flag.Parse()
 
-	config, err := configuration.Load(*configLocation)
+	command := flag.Arg(0)
+	mode := configuration.ModeConfigured
+	switch command {
+	case "":
+	case "serve":
+		mode = configuration.ModeServe
+	default:
+		mode = configuration.ModeCommand
+		// unknown commands are rejected by RunCommand
+		if command, err := cli.Lookup(flag.Args()); err == nil && command.Clients {
+			mode = configuration.ModeClientCommand
+		}
+	}
+
+	config, err := configuration.Load(*configLocation, mode)
 	if err != nil {
 		log.Fatal(err)
 	}
 	_log.Init(config)
 
+	if mode == configuration.ModeCommand || mode == configuration.ModeClientCommand {
+		err = pkg.RunCommand(context.Background(), config, flag.Args(), os.Stdout)
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	code := 0
@@ -29,11 +29,15 @@ import (
 	"github.com/SENERGY-Platform/billing/pkg/configuration"
 	"github.com/SENERGY-Platform/billing/pkg/controller"
 	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
 	"github.com/SENERGY-Platform/billing/pkg/model"
 	gin_mw "github.com/SENERGY-Platform/gin-middleware"
 	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var endpoints = []func(router *gin.Engine, config configuration.Config, controller *controller.Controller){}
@@ -42,6 +46,10 @@ type userQuery struct {
 	ForUser string `form:"for_user"`
 }
 
+type currencyQuery struct {
+	Currency string `form:"currency"`
+}
+
 // Start godoc
 // @title billing API
 // @description Gets billing information for users
@@ -49,7 +57,7 @@ type userQuery struct {
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
-func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, controller *controller.Controller) (err error) {
+func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, controller *controller.Controller, metrics *metrics.Metrics) (err error) {
 	log.Logger.Info("start api")
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
@@ -61,12 +69,17 @@ func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config,
 			nil,
 		),
 		requestid.New(requestid.WithCustomHeaderStrKey("X-Request-ID")),
+		otelgin.Middleware(config.OtelServiceName),
+		requestIdSpanAttribute,
+		metrics.GinMiddleware(),
+		compression,
 		gin_mw.ErrorHandler(model.GetStatusCode, ", "),
 		gin_mw.StructRecoveryHandler(log.Logger, gin_mw.DefaultRecoveryFunc),
 	)
 	for _, endpoint := range endpoints {
 		endpoint(router, config, controller)
 	}
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 	server := &http.Server{Addr: ":" + config.ApiPort, Handler: router, WriteTimeout: 120 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
 	wg.Add(1)
 	go func() {
@@ -89,6 +102,12 @@ func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config,
 	return nil
 }
 
+// requestIdSpanAttribute links the X-Request-ID of the access log to the request span.
+func requestIdSpanAttribute(c *gin.Context) {
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request.id", requestid.Get(c)))
+	c.Next()
+}
+
 func getUserId(context *gin.Context) (string, error) {
 	request := context.Request
 	query := userQuery{}
@@ -98,8 +117,7 @@ func getUserId(context *gin.Context) (string, error) {
 	}
 	forUser := query.ForUser
 	if forUser != "" {
-		roles := strings.Split(request.Header.Get("X-User-Roles"), ", ")
-		if !slices.Contains(roles, "admin") {
+		if !isAdmin(request) {
 			return "", errors.Join(model.ErrForbidden, errors.New("forbidden"))
 		}
 		return forUser, nil
@@ -107,6 +125,37 @@ func getUserId(context *gin.Context) (string, error) {
 	return request.Header.Get("X-UserId"), nil
 }
 
+// getCurrency returns the currency requested by the currency query parameter, empty if the stored currency is kept.
+// Every endpoint converting amounts reads the parameter with it, so invalid codes are rejected alike.
+func getCurrency(context *gin.Context) (string, error) {
+	query := currencyQuery{}
+	err := context.ShouldBindQuery(&query)
+	if err != nil {
+		return "", errors.Join(model.ErrBadRequest, err)
+	}
+	if query.Currency != "" && !model.ValidCurrency(query.Currency) {
+		return "", errors.Join(model.ErrBadRequest, errors.New("expected currency as ISO 4217 code like EUR, got "+query.Currency))
+	}
+	return query.Currency, nil
+}
+
+func isAdmin(request *http.Request) bool {
+	roles := strings.Split(request.Header.Get("X-User-Roles"), ", ")
+	return slices.Contains(roles, "admin")
+}
+
 func getToken(request *http.Request) (string, error) {
 	return request.Header.Get("Authorization"), nil
 }
+
+// etagMatches reports if the If-None-Match header value contains etag or is "*".
+// Weak tags match their strong counterpart, as required for If-None-Match.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for query, expected := range map[string]string{
+		"":              "",
+		"?currency=CHF": "CHF",
+		"?depth=1":      "",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/billing-components"+query, nil)
+		currency, err := getCurrency(c)
+		if err != nil || currency != expected {
+			t.Errorf("%q: expected %q, got %q %v", query, expected, currency, err)
+		}
+	}
+	for _, query := range []string{"?currency=chf", "?currency=EURO"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/billing-components"+query, nil)
+		_, err := getCurrency(c)
+		if !errors.Is(err, model.ErrBadRequest) {
+			t.Errorf("%q: expected bad request, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingAggregateEndpoints)
+}
+
+type aggregateQuery struct {
+	AllUsers bool `form:"all_users"`
+}
+
+type aggregateRangeQuery struct {
+	From string `form:"from" binding:"required"`
+	To   string `form:"to" binding:"required"`
+}
+
+type aggregateYearPath struct {
+	Year int `uri:"year" binding:"required"`
+}
+
+type aggregateQuarterPath struct {
+	Year    int `uri:"year" binding:"required"`
+	Quarter int `uri:"quarter" binding:"required,min=1,max=4"`
+}
+
+func BillingAggregateEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/billing-aggregates", getRangeAggregateHandler(config, controller))
+	router.GET("/billing-aggregates/years/:year", getYearlyAggregateHandler(config, controller))
+	router.GET("/billing-aggregates/quarters/:year/:quarter", getQuarterlyAggregateHandler(config, controller))
+}
+
+// getAggregateUserId resolves the user like getUserId, but returns an empty id for admins requesting all users.
+func getAggregateUserId(c *gin.Context) (string, error) {
+	query := aggregateQuery{}
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		return "", errors.Join(model.ErrBadRequest, err)
+	}
+	if query.AllUsers {
+		if !isAdmin(c.Request) {
+			return "", errors.Join(model.ErrForbidden, errors.New("all_users is only allowed for admins"))
+		}
+		return "", nil
+	}
+	userId, err := getUserId(c)
+	if err != nil {
+		return "", errors.Join(model.ErrBadRequest, err)
+	}
+	return userId, nil
+}
+
+// getRangeAggregateHandler godoc
+// @Summary Get aggregated billing details for a range of months
+// @Description Sums the latest billing information of every month from 'from' up to and including 'to'.
+// @Tags billing-aggregates
+// @Produce json
+// @Param for_user query string false "Target user id (admin only)"
+// @Param all_users query bool false "Aggregate over all users (admin only)"
+// @Param currency query string false "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start"
+// @Param from query string true "First month (YYYY-MM)"
+// @Param to query string true "Last month (YYYY-MM)"
+// @Success 200 {object} model.BillingAggregate
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-aggregates [get]
+func getRangeAggregateHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getAggregateUserId(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		query := aggregateRangeQuery{}
+		err = c.ShouldBindQuery(&query)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		from, err := time.Parse("2006-01", query.From)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		to, err := time.Parse("2006-01", query.To)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		aggregate, err := controller.GetAggregatedBillingInformationForMonths(c.Request.Context(), userId, from.Year(), from.Month(), to.Year(), to.Month(), currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, aggregate)
+	}
+}
+
+// getYearlyAggregateHandler godoc
+// @Summary Get aggregated billing details for a year
+// @Description Sums the latest billing information of every month of the year.
+// @Tags billing-aggregates
+// @Produce json
+// @Param for_user query string false "Target user id (admin only)"
+// @Param all_users query bool false "Aggregate over all users (admin only)"
+// @Param currency query string false "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start"
+// @Param year path int true "Year"
+// @Success 200 {object} model.BillingAggregate
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-aggregates/years/{year} [get]
+func getYearlyAggregateHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getAggregateUserId(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		path := aggregateYearPath{}
+		err = c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		aggregate, err := controller.GetYearlyAggregatedBillingInformation(c.Request.Context(), userId, path.Year, currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, aggregate)
+	}
+}
+
+// getQuarterlyAggregateHandler godoc
+// @Summary Get aggregated billing details for a quarter
+// @Description Sums the latest billing information of every month of the quarter.
+// @Tags billing-aggregates
+// @Produce json
+// @Param for_user query string false "Target user id (admin only)"
+// @Param all_users query bool false "Aggregate over all users (admin only)"
+// @Param currency query string false "Currency of the sums (ISO 4217), defaults to the configured currency. Every month is converted with the exchange rate valid at its start"
+// @Param year path int true "Year"
+// @Param quarter path int true "Quarter (1-4)"
+// @Success 200 {object} model.BillingAggregate
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-aggregates/quarters/{year}/{quarter} [get]
+func getQuarterlyAggregateHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getAggregateUserId(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		path := aggregateQuarterPath{}
+		err = c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		aggregate, err := controller.GetQuarterlyAggregatedBillingInformation(c.Request.Context(), userId, path.Year, path.Quarter, currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, aggregate)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingChangeEndpoints)
+}
+
+type changeQuery struct {
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+	Until time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func BillingChangeEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/billing-changes/:year/:month", getMonthlyBillingChangesHandler(config, controller))
+}
+
+// getMonthlyBillingChangesHandler godoc
+// @Summary List changed bills of a month
+// @Description Compares the snapshots of the month valid at 'since' and at 'until', the newest snapshot created at or before each instant, of every user.
+// @Description Users whose tree hash differs or who only have a snapshot at one of the instants are listed.
+// @Tags billing-changes
+// @Produce json
+// @Param year path int true "Year"
+// @Param month path int true "Month (1-12)"
+// @Param since query string true "First instant (RFC3339)"
+// @Param until query string false "Second instant (RFC3339), defaults to now"
+// @Success 200 {object} model.BillingChangeReport
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-changes/{year}/{month} [get]
+func getMonthlyBillingChangesHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c.Request) {
+			c.Error(errors.Join(model.ErrForbidden, errors.New("billing changes are only available for admins")))
+			return
+		}
+		path := billingMonthPath{}
+		err := c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		query := changeQuery{}
+		err = c.ShouldBindQuery(&query)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		if query.Until.IsZero() {
+			query.Until = time.Now()
+		}
+		report, err := controller.GetBillingChanges(c.Request.Context(), model.PeriodTypeMonth, controller.MonthStart(path.Year, time.Month(path.Month)), query.Since.UTC(), query.Until.UTC())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -19,6 +19,7 @@ package api
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/SENERGY-Platform/billing/pkg/configuration"
@@ -33,7 +34,19 @@ func init() {
 
 type billingMonthPath struct {
 	Year  int `uri:"year" binding:"required"`
-	Month int `uri:"month" binding:"required"`
+	Month int `uri:"month" binding:"required,min=1,max=12"`
+}
+
+type treeFilterQuery struct {
+	Path  []string `form:"path"`
+	Depth int      `form:"depth" binding:"min=0"`
+}
+
+type monthListQuery struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Limit  int    `form:"limit" binding:"min=0,max=1000"`
+	Offset int    `form:"offset" binding:"min=0"`
 }
 
 func BillingComponentEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
@@ -44,11 +57,19 @@ func BillingComponentEndpoints(router *gin.Engine, config configuration.Config,
 
 // listBillingComponentsHandler godoc
 // @Summary List available billing periods
-// @Description Returns all months for which billing information exists for the resolved user.
+// @Description Returns the months for which billing information exists for the resolved user, sorted by month,
+// @Description with the total of the latest snapshot and the number of snapshots of each month.
+// @Description The number of months matching from and to is returned in the X-Total-Count header.
 // @Tags billing-components
 // @Produce json
 // @Param for_user query string false "Target user id (admin only)"
-// @Success 200 {array} string
+// @Param from query string false "First month (YYYY-MM)"
+// @Param to query string false "Last month (YYYY-MM), included"
+// @Param limit query int false "Maximum number of months (1-1000), all months if not set"
+// @Param offset query int false "Number of months to skip"
+// @Param currency query string false "Convert the totals into this currency (ISO 4217), with the exchange rate valid at the start of each month"
+// @Success 200 {array} model.BillingMonthSummary
+// @Header 200 {int} X-Total-Count "number of matching months"
 // @Failure 400 {string} ErrorResponse
 // @Failure 500 {string} ErrorResponse
 // @Router /billing-components [get]
@@ -59,24 +80,63 @@ func listBillingComponentsHandler(config configuration.Config, controller *contr
 			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
 			return
 		}
-		overview, err := controller.ListAvailableBillingInformation(c.Request.Context(), userId)
+		query := monthListQuery{}
+		err = c.ShouldBindQuery(&query)
 		if err != nil {
-			c.Error(errors.Join(model.GetError(http.StatusInternalServerError), err))
+			c.Error(errors.Join(model.ErrBadRequest, err))
 			return
 		}
-		c.JSON(http.StatusOK, overview)
+		var from, to time.Time
+		if query.From != "" {
+			month, err := time.Parse("2006-01", query.From)
+			if err != nil {
+				c.Error(errors.Join(model.ErrBadRequest, err))
+				return
+			}
+			from = controller.MonthStart(month.Year(), month.Month())
+		}
+		if query.To != "" {
+			month, err := time.Parse("2006-01", query.To)
+			if err != nil {
+				c.Error(errors.Join(model.ErrBadRequest, err))
+				return
+			}
+			to = controller.MonthStart(month.Year(), month.Month()+1)
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		months, total, err := controller.ListBillingMonths(c.Request.Context(), userId, from, to, query.Limit, query.Offset, currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, months)
 	}
 }
 
 // getMonthlyBillingComponentsHandler godoc
 // @Summary Get billing details for month
 // @Description Returns billing information for a specific year and month for the resolved user.
+// @Description The response carries an ETag derived from the tree hashes. Requests with a matching If-None-Match header are answered with 304.
+// @Description The trees can be reduced to the subtree at 'path', given as one parameter per node name starting at the top level,
+// @Description and cut after 'depth' levels counted from the selected node. Costs of removed children stay included in their parent.
+// @Description Snapshots without a node at 'path' contain an empty tree.
 // @Tags billing-components
 // @Produce json
 // @Param for_user query string false "Target user id (admin only)"
 // @Param year path int true "Year"
 // @Param month path int true "Month (1-12)"
+// @Param path query []string false "Node names from the top level down to the selected node" collectionFormat(multi)
+// @Param depth query int false "Number of levels kept, 0 keeps all"
+// @Param currency query string false "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of the month"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} model.BillingInformation
+// @Header 200 {string} ETag "entity tag of the snapshots"
+// @Success 304
 // @Failure 400 {string} ErrorResponse
 // @Failure 500 {string} ErrorResponse
 // @Router /billing-components/{year}/{month} [get]
@@ -93,11 +153,43 @@ func getMonthlyBillingComponentsHandler(config configuration.Config, controller
 			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
 			return
 		}
-		overview, err := controller.GetBillingInformation(c.Request.Context(), userId, time.Date(path.Year, time.Month(path.Month), 1, 0, 0, 0, 0, time.UTC))
+		query := treeFilterQuery{}
+		err = c.ShouldBindQuery(&query)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		filter := model.TreeFilter{Path: query.Path, Depth: query.Depth}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		converter, err := controller.CurrencyConverter(c.Request.Context(), currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		overview, err := controller.GetFilteredMonthlyBillingInformation(c.Request.Context(), userId, path.Year, time.Month(path.Month), filter)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusInternalServerError), err))
+			return
+		}
+		overview, err = converter.ConvertAll(overview)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		etag, err := model.BillingInformationETag(overview)
 		if err != nil {
 			c.Error(errors.Join(model.GetError(http.StatusInternalServerError), err))
 			return
 		}
+		c.Header("ETag", etag)
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
 		c.JSON(http.StatusOK, overview)
 	}
 }
@@ -0,0 +1,125 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingGapEndpoints)
+}
+
+type gapQuery struct {
+	From   string `form:"from" binding:"required"`
+	To     string `form:"to" binding:"required"`
+	UserId string `form:"user_id"`
+}
+
+func BillingGapEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/billing-gaps", listBillingGapsHandler(config, controller))
+	router.POST("/billing-gaps/backfill", backfillBillingGapsHandler(config, controller))
+}
+
+// findGaps checks the admin role and returns the gaps of the months in the query.
+func findGaps(c *gin.Context, controller *controller.Controller) (report model.GapReport, err error) {
+	if !isAdmin(c.Request) {
+		return report, errors.Join(model.ErrForbidden, errors.New("billing gaps are only available for admins"))
+	}
+	query := gapQuery{}
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		return report, errors.Join(model.ErrBadRequest, err)
+	}
+	from, err := time.Parse("2006-01", query.From)
+	if err != nil {
+		return report, errors.Join(model.ErrBadRequest, err)
+	}
+	to, err := time.Parse("2006-01", query.To)
+	if err != nil {
+		return report, errors.Join(model.ErrBadRequest, err)
+	}
+	return controller.FindGaps(c.Request.Context(), query.UserId, controller.MonthStart(from.Year(), from.Month()), controller.MonthStart(to.Year(), to.Month()+1))
+}
+
+// listBillingGapsHandler godoc
+// @Summary List billing gaps
+// @Description Lists every month from 'from' up to and including 'to' without billing information of a keycloak user.
+// @Description Months ending before the creation of the user are not reported.
+// @Tags billing-gaps
+// @Produce json
+// @Param from query string true "First month (YYYY-MM)"
+// @Param to query string true "Last month (YYYY-MM)"
+// @Param user_id query string false "Only check this user"
+// @Success 200 {object} model.GapReport
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-gaps [get]
+func listBillingGapsHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := findGaps(c, controller)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// backfillBillingGapsHandler godoc
+// @Summary Backfill billing gaps
+// @Description Finds the gaps like GET /billing-gaps and stores the billing information of every gap in the background.
+// @Description Answers with 409 if a billing job is already running.
+// @Tags billing-gaps
+// @Produce json
+// @Param from query string true "First month (YYYY-MM)"
+// @Param to query string true "Last month (YYYY-MM)"
+// @Param user_id query string false "Only check this user"
+// @Success 202 {object} model.GapReport
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 409 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-gaps/backfill [post]
+func backfillBillingGapsHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := findGaps(c, controller)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if len(report.Gaps) > 0 {
+			err = controller.StartLocked(c.Request.Context(), "backfill of billing gaps", func(ctx context.Context) error {
+				return controller.FillGaps(ctx, report.Gaps)
+			})
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+		c.JSON(http.StatusAccepted, report)
+	}
+}
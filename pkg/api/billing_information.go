@@ -0,0 +1,147 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingInformationEndpoints)
+}
+
+// streamWriteTimeout replaces the server write timeout for every streamed line, so large exports are not cut off.
+const streamWriteTimeout = 2 * time.Minute
+
+type billingInformationQuery struct {
+	PeriodType string `form:"period_type" binding:"omitempty,oneof=month quarter week custom"`
+	From       string `form:"from" binding:"required"`
+	To         string `form:"to" binding:"required"`
+	UserId     string `form:"user_id"`
+}
+
+// streamError ends a stream which could not be completed.
+type streamError struct {
+	Error string `json:"error"`
+}
+
+func BillingInformationEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/billing-information", streamBillingInformationHandler(config, controller))
+}
+
+// streamBillingInformationHandler godoc
+// @Summary Export billing information
+// @Description Streams the latest snapshot of every user and period starting between from and to, both included, as newline delimited json.
+// @Description Every line is a model.UserBillingInformation. The snapshots are read one by one from the database.
+// @Description If reading fails after the first line was sent, the last line is an object with an 'error' field.
+// @Tags billing-information
+// @Produce application/x-ndjson
+// @Param period_type query string false "Period type, defaults to month" Enums(month, quarter, week, custom)
+// @Param from query string true "First day (YYYY-MM-DD)"
+// @Param to query string true "Last day (YYYY-MM-DD)"
+// @Param user_id query string false "Only export this user"
+// @Param currency query string false "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of each period"
+// @Success 200 {object} model.UserBillingInformation
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-information [get]
+func streamBillingInformationHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c.Request) {
+			c.Error(errors.Join(model.ErrForbidden, errors.New("billing information export is only available for admins")))
+			return
+		}
+		query := billingInformationQuery{}
+		err := c.ShouldBindQuery(&query)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		if query.PeriodType == "" {
+			query.PeriodType = model.PeriodTypeMonth
+		}
+		from, err := time.Parse(time.DateOnly, query.From)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		to, err := time.Parse(time.DateOnly, query.To)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		converter, err := controller.CurrencyConverter(c.Request.Context(), currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		responseController := http.NewResponseController(c.Writer)
+		encoder := json.NewEncoder(c.Writer)
+		written := false
+		err = controller.EachLatestBillingInformation(c.Request.Context(), query.UserId, query.PeriodType, controller.DayStart(from.Year(), from.Month(), from.Day()), controller.DayStart(to.Year(), to.Month(), to.Day()+1), func(info model.BillingInformation) error {
+			info, err := converter.Convert(info)
+			if err != nil {
+				return err
+			}
+			if !written {
+				c.Header("Content-Type", contentTypeNdjson)
+				c.Status(http.StatusOK)
+				written = true
+			}
+			_ = responseController.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			err = encoder.Encode(model.UserBillingInformation{UserId: info.UserId, BillingInformation: info})
+			if err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+		if err != nil && !written {
+			c.Error(err)
+			return
+		}
+		if err != nil {
+			// the status is already sent, so the error is reported as last line
+			log.Logger.Error("billing information export aborted", attributes.ErrorKey, err)
+			_ = encoder.Encode(streamError{Error: err.Error()})
+			c.Abort()
+			return
+		}
+		if !written {
+			c.Header("Content-Type", contentTypeNdjson)
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingPeriodEndpoints)
+}
+
+type billingPeriodTypePath struct {
+	Type string `uri:"type" binding:"required,oneof=month quarter week custom"`
+}
+
+type billingPeriodPath struct {
+	Type  string `uri:"type" binding:"required,oneof=month quarter week custom"`
+	Start string `uri:"start" binding:"required"`
+}
+
+func BillingPeriodEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/billing-periods/:type", listBillingPeriodsHandler(config, controller))
+	router.GET("/billing-periods/:type/:start", getBillingPeriodHandler(config, controller))
+}
+
+// listBillingPeriodsHandler godoc
+// @Summary List available billing periods of a type
+// @Description Returns the start of every period of the given type for which billing information exists for the resolved user.
+// @Tags billing-periods
+// @Produce json
+// @Param for_user query string false "Target user id (admin only)"
+// @Param type path string true "Period type" Enums(month, quarter, week, custom)
+// @Success 200 {array} string
+// @Failure 400 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-periods/{type} [get]
+func listBillingPeriodsHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getUserId(c)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		path := billingPeriodTypePath{}
+		err = c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		periods, err := controller.ListAvailableBillingInformation(c.Request.Context(), userId, path.Type)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusInternalServerError), err))
+			return
+		}
+		c.JSON(http.StatusOK, periodStarts(periods))
+	}
+}
+
+// getBillingPeriodHandler godoc
+// @Summary Get billing details for period
+// @Description Returns billing information for the period of the given type starting on the given day (in the billing time zone) for the resolved user.
+// @Tags billing-periods
+// @Produce json
+// @Param for_user query string false "Target user id (admin only)"
+// @Param type path string true "Period type" Enums(month, quarter, week, custom)
+// @Param start path string true "First day of the period (YYYY-MM-DD)"
+// @Param currency query string false "Convert all amounts into this currency (ISO 4217), with the exchange rate valid at the start of the period"
+// @Success 200 {array} model.BillingInformation
+// @Failure 400 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-periods/{type}/{start} [get]
+func getBillingPeriodHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getUserId(c)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		path := billingPeriodPath{}
+		err = c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		start, err := time.Parse(time.DateOnly, path.Start)
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusBadRequest), err))
+			return
+		}
+		currency, err := getCurrency(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		converter, err := controller.CurrencyConverter(c.Request.Context(), currency)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		overview, err := controller.GetPeriodBillingInformation(c.Request.Context(), userId, path.Type, start.Year(), start.Month(), start.Day())
+		if err != nil {
+			c.Error(errors.Join(model.GetError(http.StatusInternalServerError), err))
+			return
+		}
+		overview, err = converter.ConvertAll(overview)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, overview)
+	}
+}
+
+// periodStarts returns the start of every period, the response format of the period listings.
+func periodStarts(periods []model.BillingPeriodSummary) []time.Time {
+	result := make([]time.Time, 0, len(periods))
+	for _, period := range periods {
+		result = append(result, period.From)
+	}
+	return result
+}
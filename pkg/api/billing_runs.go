@@ -0,0 +1,98 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, BillingRunEndpoints)
+}
+
+func BillingRunEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.POST("/billing-runs", triggerBillingRunHandler(config, controller))
+}
+
+// triggerBillingRunHandler godoc
+// @Summary Trigger a billing run
+// @Description Runs the billing job for the requested periods, or for the periods configured for the job if from and to are empty. Ranges of more than 260 periods are rejected with 400.
+// @Description A dry run compares the fetched trees with the latest stored snapshots and returns the report without storing anything.
+// @Description Other runs are started in the background and answered with the periods to be stored, or with 409 if a billing job is already running.
+// @Tags billing-runs
+// @Accept json
+// @Produce json
+// @Param request body model.BillingRunRequest true "Billing run"
+// @Success 200 {object} model.DryRunReport
+// @Success 202 {array} model.Period
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 409 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /billing-runs [post]
+func triggerBillingRunHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c.Request) {
+			c.Error(errors.Join(model.ErrForbidden, errors.New("billing runs may only be triggered by admins")))
+			return
+		}
+		request := model.BillingRunRequest{}
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		periods, err := controller.BillingRunPeriods(request, time.Now())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if len(periods) == 0 {
+			c.Error(errors.Join(model.ErrBadRequest, errors.New("no periods to bill")))
+			return
+		}
+		if request.DryRun {
+			report, err := controller.DryRunBillingInformation(c.Request.Context(), request.UserId, periods)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.JSON(http.StatusOK, report)
+			return
+		}
+		ctx := c.Request.Context()
+		if request.RunId != "" {
+			ctx = controller.WithRunId(ctx, request.RunId)
+		}
+		err = controller.StartLocked(ctx, "triggered billing run of user '"+request.UserId+"'", func(ctx context.Context) error {
+			return controller.StoreBillingInformationForUser(ctx, request.UserId, periods)
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusAccepted, periods)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const encodingBrotli = "br"
+const encodingGzip = "gzip"
+
+const contentTypeNdjson = "application/x-ndjson"
+
+// json responses written in a single write below this size are not compressed
+const minCompressionSize = 1024
+
+// encoder is implemented by *gzip.Writer and *brotli.Writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compression compresses json and ndjson responses with brotli or gzip, as negotiated through Accept-Encoding.
+func compression(c *gin.Context) {
+	c.Header("Vary", "Accept-Encoding")
+	encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+	if encoding == "" || c.Request.Method == http.MethodHead {
+		c.Next()
+		return
+	}
+	writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+	c.Writer = writer
+	defer func() {
+		if writer.encoder != nil {
+			_ = writer.encoder.Close()
+		}
+	}()
+	c.Next()
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality value in the Accept-Encoding header,
+// preferring brotli on ties, or "" if the response should not be compressed.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		switch name {
+		case encodingBrotli, encodingGzip:
+			qualities[name] = quality
+		case "*":
+			for _, encoding := range []string{encodingBrotli, encodingGzip} {
+				if _, set := qualities[encoding]; !set {
+					qualities[encoding] = quality
+				}
+			}
+		}
+	}
+	result := ""
+	for _, encoding := range []string{encodingBrotli, encodingGzip} {
+		if quality, ok := qualities[encoding]; ok && quality > 0 && (result == "" || quality > qualities[result]) {
+			result = encoding
+		}
+	}
+	return result
+}
+
+// compressWriter decides on the first write, from the response headers, if the body is compressed.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	decided  bool
+	encoder  encoder
+}
+
+func (this *compressWriter) decide(firstWrite int) {
+	this.decided = true
+	header := this.Header()
+	contentType := header.Get("Content-Type")
+	streamed := strings.HasPrefix(contentType, contentTypeNdjson)
+	if !streamed && (!strings.HasPrefix(contentType, "application/json") || firstWrite < minCompressionSize) {
+		return
+	}
+	status := this.Status()
+	if header.Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+	header.Set("Content-Encoding", this.encoding)
+	header.Del("Content-Length")
+	// the compressed body differs byte by byte from the one the strong entity tag was computed for
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	switch this.encoding {
+	case encodingBrotli:
+		this.encoder = brotli.NewWriterLevel(this.ResponseWriter, brotli.DefaultCompression)
+	default:
+		this.encoder = gzip.NewWriter(this.ResponseWriter)
+	}
+}
+
+func (this *compressWriter) Write(data []byte) (int, error) {
+	if !this.decided {
+		this.decide(len(data))
+	}
+	if this.encoder == nil {
+		return this.ResponseWriter.Write(data)
+	}
+	this.ResponseWriter.WriteHeaderNow()
+	return this.encoder.Write(data)
+}
+
+func (this *compressWriter) WriteString(s string) (int, error) {
+	return this.Write([]byte(s))
+}
+
+// Flush sends the data compressed so far, so streamed responses reach the client without waiting for the end.
+func (this *compressWriter) Flush() {
+	if this.encoder != nil {
+		_ = this.encoder.Flush()
+	}
+	this.ResponseWriter.Flush()
+}
+
+func (this *compressWriter) Unwrap() http.ResponseWriter {
+	return this.ResponseWriter
+}
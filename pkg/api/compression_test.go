@@ -0,0 +1,126 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                     "",
+		"identity":             "",
+		"gzip":                 "gzip",
+		"gzip, deflate, br":    "br",
+		"br;q=0.5, gzip;q=0.8": "gzip",
+		"br;q=0, gzip":         "gzip",
+		"*":                    "br",
+		"*;q=0.1, gzip;q=0.5":  "gzip",
+		"GZIP;q=1.0, br;q=0":   "gzip",
+		"gzip;q=0, br;q=0, *":  "",
+		"gzip;q=invalid, br":   "br",
+	} {
+		if actual := negotiateEncoding(header); actual != expected {
+			t.Errorf("%q: expected %q, got %q", header, expected, actual)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat("x", 2*minCompressionSize)
+	router := gin.New()
+	router.Use(compression)
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []string{large})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "small")
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", contentTypeNdjson)
+		c.Status(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			_, _ = c.Writer.WriteString("{}\n")
+			c.Writer.Flush()
+		}
+	})
+
+	request := func(path string, acceptEncoding string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	decode := func(t *testing.T, recorder *httptest.ResponseRecorder) string {
+		var reader io.Reader = recorder.Body
+		switch recorder.Header().Get("Content-Encoding") {
+		case encodingGzip:
+			gzipReader, err := gzip.NewReader(recorder.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gzipReader
+		case encodingBrotli:
+			reader = brotli.NewReader(recorder.Body)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingBrotli} {
+		recorder := request("/large", encoding)
+		if recorder.Header().Get("Content-Encoding") != encoding {
+			t.Errorf("expected %v encoding, got %q", encoding, recorder.Header().Get("Content-Encoding"))
+		}
+		if recorder.Body.Len() >= len(large) {
+			t.Errorf("%v: expected compressed body, got %v bytes", encoding, recorder.Body.Len())
+		}
+		if body := decode(t, recorder); body != `["`+large+`"]` {
+			t.Errorf("%v: unexpected body of %v bytes", encoding, len(body))
+		}
+
+		recorder = request("/stream", encoding)
+		if recorder.Header().Get("Content-Encoding") != encoding {
+			t.Errorf("expected compressed stream, got %q", recorder.Header().Get("Content-Encoding"))
+		}
+		if body := decode(t, recorder); body != "{}\n{}\n{}\n" {
+			t.Errorf("%v: unexpected stream %q", encoding, body)
+		}
+	}
+
+	recorder := request("/small", "gzip")
+	if recorder.Header().Get("Content-Encoding") != "" || recorder.Body.String() != `"small"` {
+		t.Errorf("small responses should not be compressed, got %q %q", recorder.Header().Get("Content-Encoding"), recorder.Body.String())
+	}
+	recorder = request("/large", "")
+	if recorder.Header().Get("Content-Encoding") != "" || recorder.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("unexpected headers without Accept-Encoding %v", recorder.Header())
+	}
+}
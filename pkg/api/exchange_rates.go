@@ -0,0 +1,135 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, ExchangeRateEndpoints)
+}
+
+type exchangeRatePath struct {
+	From      string `uri:"from" binding:"required"`
+	To        string `uri:"to" binding:"required"`
+	ValidFrom string `uri:"valid_from" binding:"required"`
+}
+
+func ExchangeRateEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/exchange-rates", listExchangeRatesHandler(config, controller))
+	router.PUT("/exchange-rates", setExchangeRateHandler(config, controller))
+	router.DELETE("/exchange-rates/:from/:to/:valid_from", removeExchangeRateHandler(config, controller))
+}
+
+// listExchangeRatesHandler godoc
+// @Summary List exchange rates
+// @Description Returns the exchange rates used by the currency parameter of the read endpoints, sorted by currencies and validity.
+// @Description A rate is valid from its valid_from until the valid_from of the next rate of the same currencies.
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {array} model.ExchangeRate
+// @Failure 500 {string} ErrorResponse
+// @Router /exchange-rates [get]
+func listExchangeRatesHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rates, err := controller.ListExchangeRates(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, rates)
+	}
+}
+
+// setExchangeRateHandler godoc
+// @Summary Set exchange rate
+// @Description Stores an exchange rate, replacing the rate of the same currencies with the same valid_from.
+// @Description Amounts in 'from' are multiplied with 'rate' to get amounts in 'to'. The inverse direction is derived if no rate for it is stored.
+// @Tags exchange-rates
+// @Accept json
+// @Param rate body model.ExchangeRate true "Exchange rate"
+// @Success 204
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /exchange-rates [put]
+func setExchangeRateHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c.Request) {
+			c.Error(errors.Join(model.ErrForbidden, errors.New("exchange rates may only be changed by admins")))
+			return
+		}
+		rate := model.ExchangeRate{}
+		err := c.ShouldBindJSON(&rate)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		err = controller.SetExchangeRate(c.Request.Context(), rate)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// removeExchangeRateHandler godoc
+// @Summary Remove exchange rate
+// @Description Removes the exchange rate of the currencies with the given valid_from.
+// @Tags exchange-rates
+// @Param from path string true "Source currency (ISO 4217)"
+// @Param to path string true "Target currency (ISO 4217)"
+// @Param valid_from path string true "Start of validity (RFC 3339)"
+// @Success 204
+// @Failure 400 {string} ErrorResponse
+// @Failure 403 {string} ErrorResponse
+// @Failure 500 {string} ErrorResponse
+// @Router /exchange-rates/{from}/{to}/{valid_from} [delete]
+func removeExchangeRateHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c.Request) {
+			c.Error(errors.Join(model.ErrForbidden, errors.New("exchange rates may only be changed by admins")))
+			return
+		}
+		path := exchangeRatePath{}
+		err := c.ShouldBindUri(&path)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		validFrom, err := time.Parse(time.RFC3339, path.ValidFrom)
+		if err != nil {
+			c.Error(errors.Join(model.ErrBadRequest, err))
+			return
+		}
+		err = controller.RemoveExchangeRate(c.Request.Context(), path.From, path.To, validFrom)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	endpoints = append(endpoints, HealthEndpoints)
+}
+
+func HealthEndpoints(router *gin.Engine, config configuration.Config, controller *controller.Controller) {
+	router.GET("/health/live", livenessHandler)
+	router.GET("/health/ready", readinessHandler(config, controller))
+}
+
+// livenessHandler godoc
+// @Summary Liveness probe
+// @Description Reports that the process is able to handle requests.
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.HealthReport
+// @Router /health/live [get]
+func livenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, model.HealthReport{Status: model.HealthStatusUp})
+}
+
+// readinessHandler godoc
+// @Summary Readiness probe
+// @Description Checks the database and, if configured, the cost calculator and keycloak. Reports the state of every dependency.
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.HealthReport
+// @Failure 503 {object} model.HealthReport
+// @Router /health/ready [get]
+func readinessHandler(config configuration.Config, controller *controller.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := controller.Readiness(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != model.HealthStatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package calculator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// Client requests cost trees from the cost calculator. Unlike github.com/SENERGY-Platform/cost-calculator/pkg/client
+// it passes the context to the request, so the trace context is propagated.
+type Client interface {
+	GetTree(ctx context.Context, token string, skipEstimation bool, start *time.Time, end *time.Time, forUser *string) (model.CostTree, error)
+}
+
+type impl struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+func New(baseUrl string) Client {
+	return &impl{baseUrl: baseUrl, httpClient: &http.Client{Transport: tracing.Transport()}}
+}
+
+func (c *impl) GetTree(ctx context.Context, token string, skipEstimation bool, start *time.Time, end *time.Time, forUser *string) (model.CostTree, error) {
+	query := url.Values{}
+	query.Set("skip_estimation", strconv.FormatBool(skipEstimation))
+	if start != nil {
+		query.Set("start", start.Format(time.RFC3339))
+	}
+	if end != nil {
+		query.Set("end", end.Format(time.RFC3339))
+	}
+	if forUser != nil {
+		query.Set("for_user", *forUser)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/tree?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		_, _ = io.ReadAll(resp.Body) //read error response end ensure that resp.Body is read to EOF
+		return nil, fmt.Errorf("unexpected statuscode %v", resp.StatusCode)
+	}
+	tree := model.CostTree{}
+	err = json.NewDecoder(resp.Body).Decode(&tree)
+	if err != nil {
+		_, _ = io.ReadAll(resp.Body) //ensure resp.Body is read to EOF
+		return nil, err
+	}
+	return tree, nil
+}
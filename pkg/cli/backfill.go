@@ -0,0 +1,76 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "backfill",
+		Description: "store the billing information of a range of months, skipping months with a final snapshot",
+		Clients:     true,
+		Run:         backfill,
+	})
+}
+
+func backfill(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("backfill")
+	from := flags.String("from", "", "first month (YYYY-MM)")
+	to := flags.String("to", "", "last month (YYYY-MM), included in the backfill")
+	user := flags.String("user", "", "only backfill this user, by default all users are backfilled")
+	runId := flags.String("run-id", "", "id of the run, retrying a run with the same id replaces its snapshots (default random)")
+	force := flags.Bool("force", false, "also store months which already have a final snapshot")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "from", "to"); err != nil {
+		return err
+	}
+	fromTime, err := parseMonth(controller, *from)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseMonth(controller, *to)
+	if err != nil {
+		return err
+	}
+	if toTime.After(time.Now()) {
+		return errors.New("backfill: --to must not lie in the future")
+	}
+	localTo := toTime.In(controller.Location())
+	periods, err := controller.PeriodsBetween(model.PeriodTypeMonth, fromTime, controller.MonthStart(localTo.Year(), localTo.Month()+1))
+	if err != nil {
+		return err
+	}
+	if *runId != "" {
+		ctx = controller.WithRunId(ctx, *runId)
+	}
+	return controller.RunLocked(ctx, func(ctx context.Context) error {
+		return controller.BackfillBillingInformation(ctx, *user, periods, *force, func(progress model.BackfillProgress) {
+			fmt.Fprintf(out, "[%v/%v] %v: stored %v, skipped %v, unchanged %v\n", progress.Index, progress.Total, progress.Period.From.In(controller.Location()).Format("2006-01"), progress.Stored, progress.Skipped, progress.Unchanged)
+		})
+	})
+}
@@ -0,0 +1,145 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands,
+		Command{
+			Name:        "list",
+			Description: "list the periods with stored billing information of a user",
+			Run:         list,
+		},
+		Command{
+			Name:        "show",
+			Description: "print all snapshots of a month of a user as json",
+			Run:         show,
+		},
+		Command{
+			Name:        "delete",
+			Description: "delete snapshots of a month of a user",
+			Run:         remove,
+		},
+	)
+}
+
+func list(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("list")
+	user := flags.String("user", "", "user id")
+	periodType := flags.String("type", model.PeriodTypeMonth, "period type (month, quarter, week or custom)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	err := errors.Join(requireFlags(flags, "user"), validatePeriodType(*periodType))
+	if err != nil {
+		return err
+	}
+	periods, err := controller.ListAvailableBillingInformation(ctx, *user, *periodType)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "FROM\tSNAPSHOTS\tLATEST")
+	for _, period := range periods {
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", period.From.In(controller.Location()).Format(time.DateOnly), period.Snapshots, period.LatestCreatedAt.Format(time.RFC3339))
+	}
+	return writer.Flush()
+}
+
+func show(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("show")
+	user := flags.String("user", "", "user id")
+	month := flags.String("month", "", "month (YYYY-MM)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "user", "month"); err != nil {
+		return err
+	}
+	from, err := parseMonth(controller, *month)
+	if err != nil {
+		return err
+	}
+	infos, err := controller.GetBillingInformation(ctx, *user, model.PeriodTypeMonth, from)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(infos)
+}
+
+func remove(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("delete")
+	user := flags.String("user", "", "user id")
+	month := flags.String("month", "", "month (YYYY-MM)")
+	createdAt := flags.String("created-at", "", "only delete the snapshot created at this time (RFC3339), as printed by show")
+	all := flags.Bool("all", false, "delete all snapshots of the month, required if --created-at is not set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "user", "month"); err != nil {
+		return err
+	}
+	if *createdAt == "" && !*all {
+		return errors.New("delete: missing --created-at or --all")
+	}
+	from, err := parseMonth(controller, *month)
+	if err != nil {
+		return err
+	}
+	infos, err := controller.GetBillingInformation(ctx, *user, model.PeriodTypeMonth, from)
+	if err != nil {
+		return err
+	}
+	if *createdAt != "" {
+		createdAtTime, err := time.Parse(time.RFC3339Nano, *createdAt)
+		if err != nil {
+			return fmt.Errorf("invalid created-at '%v', expected RFC3339", *createdAt)
+		}
+		filtered := []model.BillingInformation{}
+		for _, info := range infos {
+			if info.CreatedAt.Equal(createdAtTime) {
+				filtered = append(filtered, info)
+			}
+		}
+		infos = filtered
+	}
+	if len(infos) == 0 {
+		return errors.New("delete: no matching billing information found")
+	}
+	for _, info := range infos {
+		err = controller.RemoveBillingInformation(ctx, *user, info.PeriodType, info.From, info.CreatedAt)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "deleted snapshot of %v created at %v\n", *month, info.CreatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
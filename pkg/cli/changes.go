@@ -0,0 +1,97 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "changes",
+		Description: "list users whose bill of a month changed between two instants",
+		Run:         changes,
+	})
+}
+
+func changes(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("changes")
+	month := flags.String("month", "", "month (YYYY-MM)")
+	since := flags.String("since", "", "first instant (RFC3339)")
+	until := flags.String("until", "", "second instant (RFC3339), defaults to now")
+	format := flags.String("format", "text", "output format (text or json)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "month", "since"); err != nil {
+		return err
+	}
+	from, err := parseMonth(controller, *month)
+	if err != nil {
+		return err
+	}
+	sinceTime, err := time.Parse(time.RFC3339, *since)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	untilTime := time.Now()
+	if *until != "" {
+		untilTime, err = time.Parse(time.RFC3339, *until)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	report, err := controller.GetBillingChanges(ctx, model.PeriodTypeMonth, from, sinceTime.UTC(), untilTime.UTC())
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "text":
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "USER\tOLD\tNEW")
+		for _, change := range report.Changes {
+			fmt.Fprintf(writer, "%v\t%v\t%v\n", change.UserId, formatSnapshot(change.Old), formatSnapshot(change.New))
+		}
+		err = writer.Flush()
+		if err == nil {
+			fmt.Fprintf(out, "%v changed bills\n", len(report.Changes))
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown format '%v', expected text or json", *format)
+	}
+}
+
+func formatSnapshot(snapshot *model.Snapshot) string {
+	if snapshot == nil {
+		return "-"
+	}
+	return snapshot.CreatedAt.Format(time.RFC3339) + " (" + snapshot.Hash[:min(12, len(snapshot.Hash))] + ")"
+}
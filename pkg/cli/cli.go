@@ -0,0 +1,115 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+// Command is an operational subcommand working on the same controller as the api and the billing job.
+type Command struct {
+	Name        string
+	Description string
+	// Clients is set by commands fetching users from keycloak or trees from the calculator, so their settings are required.
+	Clients bool
+	Run     func(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error
+}
+
+var commands = []Command{}
+
+// Commands returns all registered subcommands. serve is handled by the caller, as it needs the full service setup.
+func Commands() []Command {
+	return commands
+}
+
+// Lookup returns the subcommand named by args[0]. It allows rejecting unknown commands before setting up the controller.
+func Lookup(args []string) (Command, error) {
+	if len(args) == 0 {
+		return Command{}, errors.New("missing command")
+	}
+	for _, command := range commands {
+		if command.Name == args[0] {
+			return command, nil
+		}
+	}
+	return Command{}, fmt.Errorf("unknown command '%v'", args[0])
+}
+
+// Run executes the subcommand named by args[0] with the remaining args as its flags.
+func Run(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	command, err := Lookup(args)
+	if err != nil {
+		return err
+	}
+	return command.Run(ctx, controller, args[1:], out)
+}
+
+// Usage writes the list of subcommands.
+func Usage(out io.Writer) {
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintf(out, "  %-10v %v\n", "serve", "start the api server without running the billing job")
+	for _, command := range commands {
+		fmt.Fprintf(out, "  %-10v %v\n", command.Name, command.Description)
+	}
+	fmt.Fprintln(out, "Without command, job and server from the configuration decide what is started.")
+	fmt.Fprintln(out, "Use '<command> -h' for the flags of a command.")
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+func requireFlags(flags *flag.FlagSet, names ...string) error {
+	missing := []string{}
+	for _, name := range names {
+		if flags.Lookup(name).Value.String() == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%v: missing %v", flags.Name(), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// parseMonth parses a month as YYYY-MM and returns the first instant of the month in the billing time zone.
+func parseMonth(controller *controller.Controller, value string) (time.Time, error) {
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month '%v', expected YYYY-MM", value)
+	}
+	return controller.MonthStart(month.Year(), month.Month()), nil
+}
+
+func validatePeriodType(periodType model.PeriodType) error {
+	if !model.IsValidPeriodType(periodType) {
+		return fmt.Errorf("invalid period type '%v'", periodType)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func newTestController(t *testing.T, db database.Database) *controller.Controller {
+	config := &configuration.ConfigStruct{BillingTimeZone: "Europe/Berlin"}
+	ctrl, err := controller.NewControllerWithClients(context.Background(), &sync.WaitGroup{}, config, func(err error) {}, db, events.NewMemory(), metrics.New(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctrl
+}
+
+func storeTestBillingInformation(t *testing.T, db database.Database, userId string, from time.Time, createdAt time.Time, cpu float64) {
+	err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+		PeriodType: model.PeriodTypeMonth,
+		From:       from,
+		To:         from.AddDate(0, 1, 0),
+		CreatedAt:  createdAt,
+		UserId:     userId,
+		Tree: calcmodel.CostTree{
+			"analytics": {
+				CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: cpu, Ram: 1}},
+				Children: map[string]calcmodel.CostWithChildren{
+					"pipeline": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: cpu}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func run(t *testing.T, ctrl *controller.Controller, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	err := Run(context.Background(), ctrl, args, out)
+	return out.String(), err
+}
+
+func TestLookup(t *testing.T) {
+	command, err := Lookup([]string{"list", "--user", "user-a"})
+	if err != nil || command.Name != "list" {
+		t.Errorf("expected list command, got %v %v", command.Name, err)
+	}
+	if _, err = Lookup([]string{"lsit"}); err == nil || !strings.Contains(err.Error(), "unknown command 'lsit'") {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+	if _, err = Lookup(nil); err == nil {
+		t.Error("expected error for missing command")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	db := database.NewMemory()
+	ctrl := newTestController(t, db)
+	january := ctrl.MonthStart(2025, time.January)
+	first := time.Date(2025, 2, 1, 3, 0, 0, 0, time.UTC)
+	second := time.Date(2025, 2, 2, 3, 0, 0, 0, time.UTC)
+	storeTestBillingInformation(t, db, "user-a", january, first, 1)
+	storeTestBillingInformation(t, db, "user-a", january, second, 2)
+	storeTestBillingInformation(t, db, "user-b", january, first, 3)
+
+	t.Run("list", func(t *testing.T) {
+		out, err := run(t, ctrl, "list", "--user", "user-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "FROM        SNAPSHOTS  LATEST\n2025-01-01  2          "+second.Format(time.RFC3339)+"\n" {
+			t.Errorf("unexpected output %q", out)
+		}
+	})
+
+	t.Run("show", func(t *testing.T) {
+		out, err := run(t, ctrl, "show", "--user", "user-a", "--month", "2025-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos := []model.BillingInformation{}
+		err = json.Unmarshal([]byte(out), &infos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 2 {
+			t.Errorf("expected 2 snapshots, got %v", out)
+		}
+	})
+
+	t.Run("export csv", func(t *testing.T) {
+		out, err := run(t, ctrl, "export", "--month", "2025-01", "--format", "csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := strings.Join([]string{
+			"user_id,period_type,from,to,created_at,path,cpu,ram,storage,requests,total",
+			"user-a,month,2024-12-31T23:00:00Z,2025-01-31T23:00:00Z,2025-02-02T03:00:00Z,analytics,2,1,0,0,3",
+			"user-a,month,2024-12-31T23:00:00Z,2025-01-31T23:00:00Z,2025-02-02T03:00:00Z,analytics/pipeline,2,0,0,0,2",
+			"user-b,month,2024-12-31T23:00:00Z,2025-01-31T23:00:00Z,2025-02-01T03:00:00Z,analytics,3,1,0,0,4",
+			"user-b,month,2024-12-31T23:00:00Z,2025-01-31T23:00:00Z,2025-02-01T03:00:00Z,analytics/pipeline,3,0,0,0,3",
+		}, "\n") + "\n"
+		if out != expected {
+			t.Errorf("expected\n%v\ngot\n%v", expected, out)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := run(t, ctrl, "delete", "--user", "user-a", "--month", "2025-01")
+		if err == nil {
+			t.Error("expected error without --created-at or --all")
+		}
+		_, err = run(t, ctrl, "delete", "--user", "user-a", "--month", "2025-01", "--created-at", second.Format(time.RFC3339))
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos, err := db.GetBillingInformation(context.Background(), "user-a", model.PeriodTypeMonth, january)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 1 || !infos[0].CreatedAt.Equal(first) {
+			t.Errorf("expected only the first snapshot to remain, got %v", infos)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"unknown"},
+			{"list"},
+			{"show", "--user", "user-a", "--month", "2025-13"},
+			{"export", "--month", "2025-01", "--format", "xml"},
+		} {
+			_, err := run(t, ctrl, args...)
+			if err == nil {
+				t.Errorf("expected error for %v", args)
+			}
+		}
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "exchange-rates",
+		Description: "list the exchange rates, or set or remove the rate of two currencies valid from a day",
+		Run:         exchangeRates,
+	})
+}
+
+func exchangeRates(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("exchange-rates")
+	from := flags.String("from", "", "source currency (ISO 4217)")
+	to := flags.String("to", "", "target currency (ISO 4217)")
+	validFrom := flags.String("valid-from", "", "first day of the validity in the billing time zone (YYYY-MM-DD)")
+	rate := flags.Float64("rate", 0, "amounts in the source currency are multiplied with the rate, set the rate if given")
+	removeRate := flags.Bool("remove", false, "remove the rate instead of setting it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *rate != 0 || *removeRate {
+		if err := requireFlags(flags, "from", "to", "valid-from"); err != nil {
+			return err
+		}
+		day, err := time.Parse(time.DateOnly, *validFrom)
+		if err != nil {
+			return fmt.Errorf("invalid day '%v', expected YYYY-MM-DD", *validFrom)
+		}
+		start := controller.DayStart(day.Year(), day.Month(), day.Day())
+		if *removeRate {
+			err = controller.RemoveExchangeRate(ctx, *from, *to, start)
+		} else {
+			err = controller.SetExchangeRate(ctx, model.ExchangeRate{From: *from, To: *to, ValidFrom: start, Rate: *rate})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	rates, err := controller.ListExchangeRates(ctx)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "FROM\tTO\tVALID_FROM\tRATE")
+	for _, rate := range rates {
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", rate.From, rate.To, rate.ValidFrom.In(controller.Location()).Format(time.RFC3339), formatFloat(rate.Rate))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,143 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "export",
+		Description: "export the latest snapshot of a month of all users or a single user",
+		Run:         export,
+	})
+}
+
+var csvHeader = []string{"user_id", "period_type", "from", "to", "created_at", "path", "cpu", "ram", "storage", "requests", "total"}
+
+func export(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("export")
+	month := flags.String("month", "", "month (YYYY-MM)")
+	user := flags.String("user", "", "only export this user, by default all users are exported")
+	format := flags.String("format", "csv", "output format (csv or json)")
+	currency := flags.String("currency", "", "convert the amounts into this currency (ISO 4217), by default they are exported in the stored currency")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "month"); err != nil {
+		return err
+	}
+	from, err := parseMonth(controller, *month)
+	if err != nil {
+		return err
+	}
+	to := controller.MonthStart(from.In(controller.Location()).Year(), from.In(controller.Location()).Month()+1)
+	converter, err := controller.CurrencyConverter(ctx, *currency)
+	if err != nil {
+		return err
+	}
+	infos, err := controller.GetLatestBillingInformation(ctx, *user, model.PeriodTypeMonth, from, to)
+	if err != nil {
+		return err
+	}
+	infos, err = converter.ConvertAll(infos)
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(infos, func(a, b model.BillingInformation) int {
+		return strings.Compare(a.UserId, b.UserId)
+	})
+	switch *format {
+	case "csv":
+		return writeCsv(out, infos)
+	case "json":
+		type exportedBillingInformation struct {
+			UserId string `json:"user_id"`
+			model.BillingInformation
+		}
+		exported := []exportedBillingInformation{}
+		for _, info := range infos {
+			exported = append(exported, exportedBillingInformation{UserId: info.UserId, BillingInformation: info})
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exported)
+	default:
+		return fmt.Errorf("unknown format '%v', expected csv or json", *format)
+	}
+}
+
+// writeCsv writes one row per node of the trees, identified by the slash separated path of the node.
+func writeCsv(out io.Writer, infos []model.BillingInformation) error {
+	writer := csv.NewWriter(out)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		prefix := []string{info.UserId, info.PeriodType, info.From.Format(time.RFC3339), info.To.Format(time.RFC3339), info.CreatedAt.Format(time.RFC3339)}
+		err = writeCsvTree(writer, prefix, "", info.Tree)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeCsvTree(writer *csv.Writer, prefix []string, path string, tree map[string]calcmodel.CostWithChildren) error {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		node := tree[key]
+		nodePath := key
+		if path != "" {
+			nodePath = path + "/" + key
+		}
+		cost := node.Month
+		row := append(slices.Clone(prefix), nodePath, formatFloat(cost.Cpu), formatFloat(cost.Ram), formatFloat(cost.Storage), formatFloat(cost.Requests), formatFloat(model.EntryTotal(cost)))
+		err := writer.Write(row)
+		if err != nil {
+			return err
+		}
+		err = writeCsvTree(writer, prefix, nodePath, node.Children)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,99 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "gaps",
+		Description: "list users and months without billing information, optionally backfilling them",
+		Clients:     true,
+		Run:         gaps,
+	})
+}
+
+func gaps(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("gaps")
+	from := flags.String("from", "", "first month (YYYY-MM)")
+	to := flags.String("to", "", "last month (YYYY-MM), included in the check")
+	user := flags.String("user", "", "only check this user, by default all users are checked")
+	format := flags.String("format", "text", "output format (text or json)")
+	fill := flags.Bool("backfill", false, "store the billing information of every gap found")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags(flags, "from", "to"); err != nil {
+		return err
+	}
+	fromTime, err := parseMonth(controller, *from)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseMonth(controller, *to)
+	if err != nil {
+		return err
+	}
+	localTo := toTime.In(controller.Location())
+	report, err := controller.FindGaps(ctx, *user, fromTime, controller.MonthStart(localTo.Year(), localTo.Month()+1))
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case "text":
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "USER\tMONTH")
+		for _, gap := range report.Gaps {
+			fmt.Fprintf(writer, "%v\t%v\n", gap.UserId, gap.Period.From.In(controller.Location()).Format("2006-01"))
+		}
+		err = writer.Flush()
+		if err == nil {
+			fmt.Fprintf(out, "%v gaps of %v users\n", len(report.Gaps), report.Users)
+		}
+	default:
+		return fmt.Errorf("unknown format '%v', expected text or json", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *fill && len(report.Gaps) > 0 {
+		err = controller.RunLocked(ctx, func(ctx context.Context) error {
+			return controller.FillGaps(ctx, report.Gaps)
+		})
+		if err != nil {
+			return err
+		}
+		if *format == "text" {
+			fmt.Fprintf(out, "backfilled %v gaps\n", len(report.Gaps))
+		}
+	}
+	return nil
+}
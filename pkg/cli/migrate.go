@@ -0,0 +1,71 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "migrate",
+		Description: "create or update the database schema and apply pending data migrations, then exit",
+		Run:         migrate,
+	})
+}
+
+// migrate relies on the database setup run before every command for tables and indexes,
+// and applies the versioned data migrations. Other commands never apply them, even with migrate_on_startup.
+// It allows running the migrations in a dedicated step before rolling out new versions.
+func migrate(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("migrate")
+	status := flags.Bool("status", false, "only list the migrations and when they were applied")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *status {
+		migrations, err := controller.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tAPPLIED_AT\tDESCRIPTION")
+		for _, m := range migrations {
+			appliedAt := "pending"
+			if m.AppliedAt != nil {
+				appliedAt = m.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%v\t%v\t%v\n", m.Version, appliedAt, m.Description)
+		}
+		return writer.Flush()
+	}
+	applied, err := controller.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		fmt.Fprintf(out, "applied migration %v: %v\n", m.Version, m.Description)
+	}
+	fmt.Fprintln(out, "database schema is up to date")
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "retention",
+		Description: "apply the retention policy, removing old provisional snapshots",
+		Run:         retention,
+	})
+}
+
+func retention(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("retention")
+	dryRun := flags.Bool("dry-run", false, "only count the snapshots which would be removed")
+	format := flags.String("format", "text", "output format (text or json)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format '%v', expected text or json", *format)
+	}
+
+	var report model.RetentionReport
+	var err error
+	if *dryRun {
+		report, err = controller.ApplyRetention(ctx, true)
+	} else {
+		err = controller.RunLocked(ctx, func(ctx context.Context) error {
+			report, err = controller.ApplyRetention(ctx, false)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	action := "removed"
+	if *dryRun {
+		action = "would remove"
+	}
+	fmt.Fprintf(out, "%v %v snapshots (%v), kept %v in %v periods\n", action, report.Removed, report.Mode, report.Kept, report.Periods)
+	return nil
+}
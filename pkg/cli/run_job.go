@@ -0,0 +1,112 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/controller"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func init() {
+	commands = append(commands, Command{
+		Name:        "run-job",
+		Description: "store billing information, by default for the periods configured for the billing job",
+		Clients:     true,
+		Run:         runJob,
+	})
+}
+
+func runJob(ctx context.Context, controller *controller.Controller, args []string, out io.Writer) error {
+	flags := newFlagSet("run-job")
+	periodType := flags.String("type", model.PeriodTypeMonth, "period type (month, quarter, week or custom)")
+	from := flags.String("from", "", "store periods starting at or after this day (YYYY-MM-DD)")
+	to := flags.String("to", "", "store periods starting before this day (YYYY-MM-DD)")
+	user := flags.String("user", "", "only store billing information of this user")
+	runId := flags.String("run-id", "", "id of the run, retrying a run with the same id replaces its snapshots (default random)")
+	dryRun := flags.Bool("dry-run", false, "compare the fetched trees with the stored snapshots without storing them")
+	format := flags.String("format", "text", "dry run report format (text or json)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	periods, err := controller.BillingRunPeriods(model.BillingRunRequest{PeriodType: *periodType, From: *from, To: *to}, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(periods) == 0 {
+		return errors.New("run-job: no periods to store")
+	}
+
+	if *dryRun {
+		report, err := controller.DryRunBillingInformation(ctx, *user, periods)
+		if err != nil {
+			return err
+		}
+		return writeDryRunReport(out, controller.Location(), report, *format)
+	}
+
+	if *runId != "" {
+		ctx = controller.WithRunId(ctx, *runId)
+	}
+	err = controller.RunLocked(ctx, func(ctx context.Context) error {
+		return controller.StoreBillingInformationForUser(ctx, *user, periods)
+	})
+	if err != nil {
+		return err
+	}
+	for _, period := range periods {
+		fmt.Fprintf(out, "stored %v %v - %v\n", period.Type, period.From.In(controller.Location()).Format(time.DateOnly), period.To.In(controller.Location()).Format(time.DateOnly))
+	}
+	return nil
+}
+
+func writeDryRunReport(out io.Writer, location *time.Location, report model.DryRunReport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "text":
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "USER\tTYPE\tFROM\tTO\tSTORED AT\tOLD TOTAL\tNEW TOTAL\tDELTA")
+		for _, entry := range report.Entries {
+			storedAt := "-"
+			if entry.StoredAt != nil {
+				storedAt = entry.StoredAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", entry.UserId, entry.PeriodType, entry.From.In(location).Format(time.DateOnly), entry.To.In(location).Format(time.DateOnly), storedAt, formatFloat(entry.OldTotal), formatFloat(entry.NewTotal), formatDelta(entry.Delta))
+		}
+		return writer.Flush()
+	default:
+		return fmt.Errorf("unknown format '%v', expected text or json", format)
+	}
+}
+
+func formatDelta(f float64) string {
+	if f > 0 {
+		return "+" + formatFloat(f)
+	}
+	return formatFloat(f)
+}
@@ -18,8 +18,10 @@ package configuration
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"reflect"
 	"regexp"
@@ -28,21 +30,61 @@ import (
 )
 
 type ConfigStruct struct {
-	Job       bool `json:"job"`
-	JobMonths int  `json:"job_months"`
-	Server    bool `json:"server"`
+	Job           bool   `json:"job"`
+	JobMonths     int    `json:"job_months"`
+	JobQuarters   int    `json:"job_quarters"`
+	JobWeeks      int    `json:"job_weeks"`
+	JobCustomFrom string `json:"job_custom_from"`
+	JobCustomTo   string `json:"job_custom_to"`
+	JobDryRun     bool   `json:"job_dry_run"`
+	JobLockTtl    string `json:"job_lock_ttl"`
+	JobLockWait   bool   `json:"job_lock_wait"`
+	JobRunId      string `json:"job_run_id"`
+	Server        bool   `json:"server"`
+
+	RetentionKeepProvisional int    `json:"retention_keep_provisional"`
+	RetentionMode            string `json:"retention_mode"`
+	RetentionInterval        string `json:"retention_interval"`
+
+	BillingTimeZone string `json:"billing_time_zone"`
+	// currency of the values returned by the cost calculator, as ISO 4217 code
+	Currency string `json:"currency"`
 
 	ApiPort       string `json:"api_port"`
 	CalculatorUrl string `json:"calculator_url"`
 
-	MongoUrl        string `json:"mongo_url"`
-	MongoReplSet    bool   `json:"mongo_repl_set"`
-	MongoCollection string `json:"mongo_collection"`
-	MongoTable      string `json:"mongo_table"`
+	DatabaseType     string `json:"database_type"`
+	MigrateOnStartup bool   `json:"migrate_on_startup"`
+
+	MongoUrl                    string `json:"mongo_url" secret:"true"`
+	MongoReplSet                bool   `json:"mongo_repl_set"`
+	MongoCollection             string `json:"mongo_collection"`
+	MongoAggregateCollection    string `json:"mongo_aggregate_collection"`
+	MongoLockCollection         string `json:"mongo_lock_collection"`
+	MongoArchiveCollection      string `json:"mongo_archive_collection"`
+	MongoMigrationCollection    string `json:"mongo_migration_collection"`
+	MongoExchangeRateCollection string `json:"mongo_exchange_rate_collection"`
+	MongoTable                  string `json:"mongo_table"`
+
+	PostgresUrl   string `json:"postgres_url" secret:"true"`
+	PostgresTable string `json:"postgres_table"`
 
 	KeycloakUrl    string `json:"keycloak_url"`
 	KeycloakClient string `json:"keycloak_client"`
-	KeycloakSecret string `json:"keycloak_secret"`
+	KeycloakSecret string `json:"keycloak_secret" secret:"true"`
+
+	KafkaUrl   string `json:"kafka_url"`
+	KafkaTopic string `json:"kafka_topic"`
+
+	PrometheusPushgatewayUrl string `json:"prometheus_pushgateway_url"`
+
+	HealthCheckCalculator bool `json:"health_check_calculator"`
+	HealthCheckKeycloak   bool `json:"health_check_keycloak"`
+
+	OtelExporter    string  `json:"otel_exporter"`
+	OtelEndpoint    string  `json:"otel_endpoint"`
+	OtelServiceName string  `json:"otel_service_name"`
+	OtelSampleRatio float64 `json:"otel_sample_ratio"`
 
 	Debug      bool   `json:"debug"`
 	LogHandler string `json:"log_handler"`
@@ -50,7 +92,53 @@ type ConfigStruct struct {
 
 type Config = *ConfigStruct
 
-func Load(location string) (config Config, err error) {
+// String returns the configuration as json with all fields tagged with `secret:"true"` redacted.
+func (this *ConfigStruct) String() string {
+	redacted := *this
+	value := reflect.ValueOf(&redacted).Elem()
+	for index := 0; index < value.NumField(); index++ {
+		if isSecret(value.Type().Field(index)) && value.Field(index).Kind() == reflect.String {
+			value.Field(index).SetString(Redact(value.Field(index).String()))
+		}
+	}
+	result, err := json.Marshal(redacted)
+	if err != nil {
+		return err.Error()
+	}
+	return string(result)
+}
+
+// Redact hides secret values for output. Urls keep everything except the password, to stay useful for debugging.
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	parsed, err := url.Parse(value)
+	if err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		return parsed.Redacted()
+	}
+	return "***"
+}
+
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// isSecretJsonField reports if the field with the given json name is tagged with `secret:"true"`.
+func isSecretJsonField(jsonName string) bool {
+	configType := reflect.TypeOf(ConfigStruct{})
+	for index := 0; index < configType.NumField(); index++ {
+		field := configType.Field(index)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == jsonName {
+			return isSecret(field)
+		}
+	}
+	return false
+}
+
+// Load reads the configuration file, applies the environment variables and the mode, and validates the result.
+// The returned error is a *ValidationError listing all problems of the environment and the configuration.
+func Load(location string, mode Mode) (config Config, err error) {
 	file, err := os.Open(location)
 	if err != nil {
 		log.Println("error on config load: ", err)
@@ -62,7 +150,19 @@ func Load(location string) (config Config, err error) {
 		log.Println("invalid config json: ", err)
 		return config, err
 	}
-	HandleEnvironmentVars(config)
+	problems := &ValidationError{}
+	var envErr *ValidationError
+	if errors.As(HandleEnvironmentVars(config), &envErr) {
+		problems.Problems = append(problems.Problems, envErr.Problems...)
+	}
+	mode.Apply(config)
+	var validationErr *ValidationError
+	if errors.As(ValidateMode(config, mode), &validationErr) {
+		problems.Problems = append(problems.Problems, validationErr.Problems...)
+	}
+	if len(problems.Problems) > 0 {
+		return config, problems
+	}
 	return config, nil
 }
 
@@ -82,55 +182,101 @@ func fieldNameToEnvName(s string) string {
 }
 
 // preparations for docker
-func HandleEnvironmentVars(config Config) {
+// values that can not be parsed into the field type are reported in the returned *ValidationError
+// every field may also be read from a file by setting <ENV_NAME>_FILE to its path, e.g. KEYCLOAK_SECRET_FILE for mounted secrets
+func HandleEnvironmentVars(config Config) error {
+	result := &ValidationError{}
 	configValue := reflect.Indirect(reflect.ValueOf(config))
 	configType := configValue.Type()
 	for index := 0; index < configType.NumField(); index++ {
 		fieldName := configType.Field(index).Name
 		envName := fieldNameToEnvName(fieldName)
 		envValue := os.Getenv(envName)
-		if envValue != "" {
-			fmt.Println("use environment variable: ", envName, " = ", envValue)
-			if configValue.FieldByName(fieldName).Kind() == reflect.Int {
-				i, _ := strconv.Atoi(envValue)
-				configValue.FieldByName(fieldName).SetInt(int64(i))
-			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Int64 {
-				i, _ := strconv.ParseInt(envValue, 10, 64)
-				configValue.FieldByName(fieldName).SetInt(i)
-			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Uint16 {
-				i, _ := strconv.ParseUint(envValue, 10, 16)
-				configValue.FieldByName(fieldName).SetUint(i)
-			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Float64 {
-				f, _ := strconv.ParseFloat(envValue, 64)
-				configValue.FieldByName(fieldName).SetFloat(f)
+		if fileLocation := os.Getenv(envName + "_FILE"); fileLocation != "" {
+			if envValue != "" {
+				result.add("%v: only one of %v and %v_FILE may be set", envName, envName, envName)
+				continue
 			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.String {
-				configValue.FieldByName(fieldName).SetString(envValue)
+			content, err := os.ReadFile(fileLocation)
+			if err != nil {
+				result.add("%v_FILE: %v", envName, err)
+				continue
 			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Bool {
-				b, _ := strconv.ParseBool(envValue)
-				configValue.FieldByName(fieldName).SetBool(b)
+			envValue = strings.TrimRight(string(content), "\r\n")
+			fmt.Println("use environment variable: ", envName+"_FILE", " = ", fileLocation)
+		} else if envValue != "" {
+			printValue := envValue
+			if isSecret(configType.Field(index)) {
+				printValue = Redact(envValue)
 			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Slice {
+			fmt.Println("use environment variable: ", envName, " = ", printValue)
+		}
+		if envValue != "" {
+			field := configValue.FieldByName(fieldName)
+			switch field.Kind() {
+			case reflect.Int:
+				i, err := strconv.Atoi(envValue)
+				if err != nil {
+					result.add("%v: expected integer, got %q", envName, envValue)
+					continue
+				}
+				field.SetInt(int64(i))
+			case reflect.Int64:
+				i, err := strconv.ParseInt(envValue, 10, 64)
+				if err != nil {
+					result.add("%v: expected integer, got %q", envName, envValue)
+					continue
+				}
+				field.SetInt(i)
+			case reflect.Uint16:
+				i, err := strconv.ParseUint(envValue, 10, 16)
+				if err != nil {
+					result.add("%v: expected integer between 0 and 65535, got %q", envName, envValue)
+					continue
+				}
+				field.SetUint(i)
+			case reflect.Float64:
+				f, err := strconv.ParseFloat(envValue, 64)
+				if err != nil {
+					result.add("%v: expected number, got %q", envName, envValue)
+					continue
+				}
+				field.SetFloat(f)
+			case reflect.String:
+				field.SetString(envValue)
+			case reflect.Bool:
+				b, err := strconv.ParseBool(envValue)
+				if err != nil {
+					result.add("%v: expected true or false, got %q", envName, envValue)
+					continue
+				}
+				field.SetBool(b)
+			case reflect.Slice:
 				val := []string{}
 				for _, element := range strings.Split(envValue, ",") {
 					val = append(val, strings.TrimSpace(element))
 				}
-				configValue.FieldByName(fieldName).Set(reflect.ValueOf(val))
-			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Map {
+				field.Set(reflect.ValueOf(val))
+			case reflect.Map:
 				value := map[string]string{}
+				valid := true
 				for _, element := range strings.Split(envValue, ",") {
-					keyVal := strings.Split(element, ":")
-					key := strings.TrimSpace(keyVal[0])
-					val := strings.TrimSpace(keyVal[1])
-					value[key] = val
+					key, val, found := strings.Cut(element, ":")
+					if !found {
+						result.add("%v: expected comma separated key:value pairs, got %q", envName, envValue)
+						valid = false
+						break
+					}
+					value[strings.TrimSpace(key)] = strings.TrimSpace(val)
+				}
+				if valid {
+					field.Set(reflect.ValueOf(value))
 				}
-				configValue.FieldByName(fieldName).Set(reflect.ValueOf(value))
 			}
 		}
 	}
+	if len(result.Problems) > 0 {
+		return result
+	}
+	return nil
 }
@@ -0,0 +1,256 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (this *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(this.Problems, "\n  - ")
+}
+
+func (this *ValidationError) add(format string, args ...interface{}) {
+	this.Problems = append(this.Problems, fmt.Sprintf(format, args...))
+}
+
+// Mode is what the binary runs. It decides which of job and server are enabled and required.
+type Mode int
+
+const (
+	// ModeConfigured runs job and server as enabled in the configuration, at least one of them is required.
+	ModeConfigured Mode = iota
+	// ModeServe only runs the server.
+	ModeServe
+	// ModeCommand runs a single cli command working on the database only. It disables job and server,
+	// so neither the billing job nor the clients of calculator and keycloak have to be configured.
+	ModeCommand
+	// ModeClientCommand is ModeCommand for commands fetching users from keycloak and trees from the calculator.
+	// Their settings are required, but not the periods of the billing job.
+	ModeClientCommand
+)
+
+// Apply enables job and server as required by the mode.
+func (this Mode) Apply(config Config) {
+	switch this {
+	case ModeServe:
+		config.Job = false
+		config.Server = true
+	case ModeCommand, ModeClientCommand:
+		config.Job = false
+		config.Server = false
+	}
+}
+
+func (this Mode) isCommand() bool {
+	return this == ModeCommand || this == ModeClientCommand
+}
+
+// Validate checks the fields required by the enabled modes (job, server) and the selected backends.
+// The returned error is a *ValidationError if problems were found.
+func Validate(config Config) error {
+	return ValidateMode(config, ModeConfigured)
+}
+
+// ValidateMode is Validate for a config the mode was applied to.
+// Other than ModeConfigured, the command modes do not require job or server to be enabled.
+func ValidateMode(config Config, mode Mode) error {
+	result := &ValidationError{}
+
+	if !config.Job && !config.Server && !mode.isCommand() {
+		result.add("at least one of job and server has to be enabled")
+	}
+
+	if config.Job || mode == ModeClientCommand {
+		validateUrl(result, "calculator_url", config.CalculatorUrl)
+		validateUrl(result, "keycloak_url", config.KeycloakUrl)
+		validateRequired(result, "keycloak_client", config.KeycloakClient)
+		validateRequired(result, "keycloak_secret", config.KeycloakSecret)
+	}
+
+	if config.Job {
+		validateRange(result, "job_months", config.JobMonths, 0, 120)
+		validateRange(result, "job_quarters", config.JobQuarters, 0, 40)
+		validateRange(result, "job_weeks", config.JobWeeks, 0, 520)
+		hasCustomPeriod := config.JobCustomFrom != "" || config.JobCustomTo != ""
+		if hasCustomPeriod {
+			from, fromErr := time.Parse(time.DateOnly, config.JobCustomFrom)
+			if fromErr != nil {
+				result.add("job_custom_from: expected date as YYYY-MM-DD, got %q", config.JobCustomFrom)
+			}
+			to, toErr := time.Parse(time.DateOnly, config.JobCustomTo)
+			if toErr != nil {
+				result.add("job_custom_to: expected date as YYYY-MM-DD, got %q", config.JobCustomTo)
+			}
+			if fromErr == nil && toErr == nil && !from.Before(to) {
+				result.add("job_custom_to: has to be after job_custom_from")
+			}
+		}
+		if config.JobMonths <= 0 && config.JobQuarters <= 0 && config.JobWeeks <= 0 && !hasCustomPeriod {
+			result.add("job: no periods to bill, set job_months, job_quarters, job_weeks or job_custom_from/job_custom_to")
+		}
+	}
+
+	if config.Server {
+		port, err := strconv.Atoi(config.ApiPort)
+		if err != nil || port < 1 || port > 65535 {
+			result.add("api_port: expected port number between 1 and 65535, got %q", config.ApiPort)
+		}
+		if config.HealthCheckCalculator {
+			validateUrl(result, "calculator_url", config.CalculatorUrl)
+		}
+		if config.HealthCheckKeycloak {
+			validateUrl(result, "keycloak_url", config.KeycloakUrl)
+		}
+	}
+
+	if config.JobLockTtl != "" {
+		ttl, err := time.ParseDuration(config.JobLockTtl)
+		if err != nil || ttl < 10*time.Second {
+			result.add("job_lock_ttl: expected duration of at least 10s, got %q", config.JobLockTtl)
+		}
+	}
+
+	retention := config.RetentionKeepProvisional > 0
+	if config.RetentionKeepProvisional < 0 {
+		result.add("retention_keep_provisional: expected 0 to disable retention or a positive number, got %v", config.RetentionKeepProvisional)
+	}
+	if retention {
+		if config.RetentionMode != "delete" && config.RetentionMode != "archive" {
+			result.add("retention_mode: expected delete or archive, got %q", config.RetentionMode)
+		}
+		if config.RetentionInterval != "" {
+			interval, err := time.ParseDuration(config.RetentionInterval)
+			if err != nil || interval < time.Minute {
+				result.add("retention_interval: expected duration of at least 1m, got %q", config.RetentionInterval)
+			}
+		}
+	}
+
+	if _, err := time.LoadLocation(config.BillingTimeZone); err != nil {
+		result.add("billing_time_zone: unknown time zone %q", config.BillingTimeZone)
+	}
+
+	if !currencyPattern.MatchString(config.Currency) {
+		result.add("currency: expected ISO 4217 code like EUR, got %q", config.Currency)
+	}
+
+	switch config.DatabaseType {
+	case "mongo", "":
+		validateUrl(result, "mongo_url", config.MongoUrl, "mongodb", "mongodb+srv")
+		validateRequired(result, "mongo_table", config.MongoTable)
+		validateRequired(result, "mongo_collection", config.MongoCollection)
+		validateRequired(result, "mongo_aggregate_collection", config.MongoAggregateCollection)
+		validateRequired(result, "mongo_lock_collection", config.MongoLockCollection)
+		validateRequired(result, "mongo_migration_collection", config.MongoMigrationCollection)
+		validateRequired(result, "mongo_exchange_rate_collection", config.MongoExchangeRateCollection)
+		if retention && config.RetentionMode == "archive" {
+			validateRequired(result, "mongo_archive_collection", config.MongoArchiveCollection)
+		}
+	case "postgres":
+		validateUrl(result, "postgres_url", config.PostgresUrl, "postgres", "postgresql")
+		validateRequired(result, "postgres_table", config.PostgresTable)
+	default:
+		result.add("database_type: expected mongo or postgres, got %q", config.DatabaseType)
+	}
+
+	if config.KafkaUrl != "" && config.KafkaUrl != "-" {
+		validateRequired(result, "kafka_topic", config.KafkaTopic)
+	}
+
+	if config.PrometheusPushgatewayUrl != "" {
+		validateUrl(result, "prometheus_pushgateway_url", config.PrometheusPushgatewayUrl)
+	}
+
+	switch config.OtelExporter {
+	case "none", "":
+	case "otlp":
+		validateUrl(result, "otel_endpoint", config.OtelEndpoint, "http", "https")
+		validateRequired(result, "otel_service_name", config.OtelServiceName)
+		if config.OtelSampleRatio < 0 || config.OtelSampleRatio > 1 {
+			result.add("otel_sample_ratio: expected value between 0 and 1, got %v", config.OtelSampleRatio)
+		}
+	default:
+		result.add("otel_exporter: expected none or otlp, got %q", config.OtelExporter)
+	}
+
+	if len(result.Problems) > 0 {
+		return result
+	}
+	return nil
+}
+
+func validateRequired(result *ValidationError, field string, value string) {
+	if value == "" {
+		result.add("%v: required", field)
+	}
+}
+
+func validateRange(result *ValidationError, field string, value int, minValue int, maxValue int) {
+	if value < minValue || value > maxValue {
+		result.add("%v: expected value between %v and %v, got %v", field, minValue, maxValue, value)
+	}
+}
+
+// validateUrl checks that value is an absolute url with host. If schemes are given, the url has to use one of them.
+func validateUrl(result *ValidationError, field string, value string, schemes ...string) {
+	if value == "" {
+		result.add("%v: required", field)
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil && isSecretJsonField(field) {
+		result.add("%v: invalid url", field)
+		return
+	}
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		result.add("%v: invalid url: %v", field, err)
+		return
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		result.add("%v: expected absolute url with scheme and host, got %q", field, redactField(field, value))
+		return
+	}
+	if len(schemes) > 0 && !slices.Contains(schemes, parsed.Scheme) {
+		result.add("%v: expected scheme %v, got %q", field, strings.Join(schemes, " or "), parsed.Scheme)
+	}
+}
+
+func redactField(field string, value string) string {
+	if isSecretJsonField(field) {
+		return Redact(value)
+	}
+	return value
+}
@@ -0,0 +1,119 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetAggregatedBillingInformation sums the latest snapshot of every month with a start in [from, to).
+// An empty userId aggregates over all users. Aggregates of closed ranges are cached.
+// Every snapshot is converted into currency with the rate valid for its month before summing, an empty currency selects
+// the configured currency. Only aggregates in the configured currency are cached, so changed exchange rates apply immediately.
+func (this *Controller) GetAggregatedBillingInformation(ctx context.Context, userId string, from time.Time, to time.Time, currency string) (aggregate model.BillingAggregate, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetAggregatedBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.to", to.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
+	if !from.Before(to) {
+		return aggregate, errors.Join(model.ErrBadRequest, errors.New("aggregation range has to end after its start"))
+	}
+	currency = cmp.Or(currency, this.config.Currency)
+	converter, err := this.CurrencyConverter(ctx, currency)
+	if err != nil {
+		return aggregate, err
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	now := time.Now().UTC()
+	cacheable := !to.After(now) && currency == this.config.Currency
+	if cacheable {
+		cached, found, err := this.db.GetAggregate(timeoutCtx, userId, from, to)
+		if err != nil {
+			return aggregate, err
+		}
+		if found {
+			span.SetAttributes(attribute.Bool("billing.cached", true))
+			// aggregates cached before currencies were recorded
+			cached.Currency = cmp.Or(cached.Currency, this.config.Currency)
+			return cached, nil
+		}
+	}
+
+	aggregate = model.BillingAggregate{
+		UserId:     userId,
+		From:       from,
+		To:         to,
+		Months:     []time.Time{},
+		ComputedAt: now,
+		Currency:   currency,
+		Tree:       calcmodel.CostTree{},
+	}
+	// the snapshots are summed while they are read, aggregates over all users do not hold every tree at once
+	err = this.db.EachLatestBillingInformation(timeoutCtx, userId, model.PeriodTypeMonth, from, to, func(info model.BillingInformation) error {
+		info, err := converter.Convert(info)
+		if err != nil {
+			return err
+		}
+		model.AddTree(aggregate.Tree, info.Tree)
+		if !slices.ContainsFunc(aggregate.Months, info.From.Equal) {
+			aggregate.Months = append(aggregate.Months, info.From)
+		}
+		return nil
+	})
+	if err != nil {
+		return aggregate, err
+	}
+	slices.SortFunc(aggregate.Months, time.Time.Compare)
+
+	if cacheable {
+		err = this.db.SetAggregate(timeoutCtx, aggregate)
+		if err != nil {
+			log.Logger.Error("unable to cache billing aggregate", "user_id", userId, attributes.ErrorKey, err)
+		}
+	}
+	return aggregate, nil
+}
+
+// GetAggregatedBillingInformationForMonths aggregates the months from fromYear/fromMonth up to and including toYear/toMonth.
+func (this *Controller) GetAggregatedBillingInformationForMonths(ctx context.Context, userId string, fromYear int, fromMonth time.Month, toYear int, toMonth time.Month, currency string) (aggregate model.BillingAggregate, err error) {
+	return this.GetAggregatedBillingInformation(ctx, userId, this.MonthStart(fromYear, fromMonth), this.MonthStart(toYear, toMonth+1), currency)
+}
+
+func (this *Controller) GetQuarterlyAggregatedBillingInformation(ctx context.Context, userId string, year int, quarter int, currency string) (aggregate model.BillingAggregate, err error) {
+	firstMonth := time.Month(3*quarter - 2)
+	return this.GetAggregatedBillingInformationForMonths(ctx, userId, year, firstMonth, year, firstMonth+2, currency)
+}
+
+func (this *Controller) GetYearlyAggregatedBillingInformation(ctx context.Context, userId string, year int, currency string) (aggregate model.BillingAggregate, err error) {
+	return this.GetAggregatedBillingInformationForMonths(ctx, userId, year, time.January, year, time.December, currency)
+}
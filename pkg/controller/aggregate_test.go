@@ -0,0 +1,121 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func cpuTree(cpu float64) calcmodel.CostTree {
+	return calcmodel.CostTree{"analytics": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: cpu}}}}
+}
+
+func TestGetAggregatedBillingInformation(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemory()
+	ctrl := newTestController(t, nil, nil, db, events.NewMemory())
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, info := range []model.BillingInformation{
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 1), CreatedAt: created, Tree: cpuTree(1)},
+		// only the latest snapshot of a month is aggregated
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 2), CreatedAt: created, Tree: cpuTree(100)},
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 2), CreatedAt: created.Add(time.Hour), Tree: cpuTree(2)},
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 4), CreatedAt: created, Tree: cpuTree(4)},
+		{UserId: "a", PeriodType: model.PeriodTypeWeek, From: ctrl.MonthStart(2024, 1), CreatedAt: created, Tree: cpuTree(1000)},
+		{UserId: "b", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 3), CreatedAt: created, Tree: cpuTree(10)},
+	} {
+		err := db.SetBillingInformation(ctx, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		get    func() (model.BillingAggregate, error)
+		cpu    float64
+		months int
+	}{
+		{"quarter", func() (model.BillingAggregate, error) {
+			return ctrl.GetQuarterlyAggregatedBillingInformation(ctx, "a", 2024, 1, "")
+		}, 3, 2},
+		{"year", func() (model.BillingAggregate, error) {
+			return ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "")
+		}, 7, 3},
+		{"all users", func() (model.BillingAggregate, error) {
+			return ctrl.GetYearlyAggregatedBillingInformation(ctx, "", 2024, "")
+		}, 17, 4},
+		{"empty", func() (model.BillingAggregate, error) {
+			return ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2023, "")
+		}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			aggregate, err := c.get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if aggregate.Tree["analytics"].Month.Cpu != c.cpu {
+				t.Errorf("expected cpu %v, got %v", c.cpu, aggregate.Tree["analytics"].Month.Cpu)
+			}
+			if len(aggregate.Months) != c.months {
+				t.Errorf("expected %v months, got %v", c.months, aggregate.Months)
+			}
+		})
+	}
+
+	// closed ranges are served from the cache until a new snapshot of a contained month is stored
+	err := db.SetBillingInformation(ctx, model.BillingInformation{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 4), CreatedAt: created.Add(time.Hour), Tree: cpuTree(40)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggregate, err := ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregate.Tree["analytics"].Month.Cpu != 7 {
+		t.Errorf("expected cached aggregate, got cpu %v", aggregate.Tree["analytics"].Month.Cpu)
+	}
+	err = db.RemoveAggregates(ctx, "a", ctrl.MonthStart(2024, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userId := range []string{"a", ""} {
+		_, found, err := db.GetAggregate(ctx, userId, ctrl.MonthStart(2024, 1), ctrl.MonthStart(2025, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found {
+			t.Errorf("expected aggregate of %q to be removed", userId)
+		}
+	}
+	aggregate, err = ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregate.Tree["analytics"].Month.Cpu != 43 {
+		t.Errorf("expected recomputed aggregate, got cpu %v", aggregate.Tree["analytics"].Month.Cpu)
+	}
+}
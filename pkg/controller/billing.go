@@ -18,19 +18,175 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (this *Controller) GetBillingInformation(ctx context.Context, userId string, from time.Time) (trees []model.BillingInformation, err error) {
+func (this *Controller) GetBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time) (trees []model.BillingInformation, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.period_type", periodType),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
-	return this.db.GetBillingInformation(timeoutCtx, userId, from)
+	return this.db.GetBillingInformation(timeoutCtx, userId, periodType, from)
 }
 
-func (this *Controller) ListAvailableBillingInformation(ctx context.Context, userId string) (dates []time.Time, err error) {
+// GetMonthlyBillingInformation returns all snapshots of the calendar month, with the month boundaries taken from the billing time zone.
+func (this *Controller) GetMonthlyBillingInformation(ctx context.Context, userId string, year int, month time.Month) (trees []model.BillingInformation, err error) {
+	return this.GetBillingInformation(ctx, userId, model.PeriodTypeMonth, this.MonthStart(year, month))
+}
+
+// GetFilteredMonthlyBillingInformation returns the snapshots of GetMonthlyBillingInformation with their trees reduced by the filter.
+// The hashes of the snapshots are replaced by the hashes of the filtered trees.
+func (this *Controller) GetFilteredMonthlyBillingInformation(ctx context.Context, userId string, year int, month time.Month, filter model.TreeFilter) (trees []model.BillingInformation, err error) {
+	trees, err = this.GetMonthlyBillingInformation(ctx, userId, year, month)
+	if err != nil || filter.IsEmpty() {
+		return trees, err
+	}
+	for i := range trees {
+		trees[i].Tree = filter.Apply(trees[i].Tree)
+		trees[i].Hash, err = model.TreeHash(trees[i].Tree)
+		if err != nil {
+			return trees, err
+		}
+	}
+	return trees, nil
+}
+
+// GetPeriodBillingInformation returns all snapshots of the period of the given type starting on the given day in the billing time zone.
+func (this *Controller) GetPeriodBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, year int, month time.Month, day int) (trees []model.BillingInformation, err error) {
+	return this.GetBillingInformation(ctx, userId, periodType, this.DayStart(year, month, day))
+}
+
+// ListAvailableBillingInformation returns the periods of the type with snapshots of the user, sorted by start, with the number of snapshots and the latest creation time.
+func (this *Controller) ListAvailableBillingInformation(ctx context.Context, userId string, periodType model.PeriodType) (periods []model.BillingPeriodSummary, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ListAvailableBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.period_type", periodType),
+	))
+	defer func() { tracing.End(span, err) }()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return this.db.ListAvailableBillingInformation(timeoutCtx, userId, periodType)
+}
+
+// ListBillingMonths returns the months with snapshots of the user starting in [from, to), sorted by start.
+// Zero from or to do not limit the range. Of the matching months, limit months starting at offset are returned,
+// all of them if limit is not positive. total is the number of matching months.
+// The totals are converted into currency, if it is not empty.
+func (this *Controller) ListBillingMonths(ctx context.Context, userId string, from time.Time, to time.Time, limit int, offset int, currency string) (months []model.BillingMonthSummary, total int, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ListBillingMonths", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.Int("billing.limit", limit),
+		attribute.Int("billing.offset", offset),
+	))
+	defer func() { tracing.End(span, err) }()
+	if offset < 0 {
+		return months, 0, errors.Join(model.ErrBadRequest, errors.New("offset has to be positive"))
+	}
+	converter, err := this.CurrencyConverter(ctx, currency)
+	if err != nil {
+		return months, 0, err
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	periods, err := this.db.ListAvailableBillingInformation(timeoutCtx, userId, model.PeriodTypeMonth)
+	if err != nil {
+		return months, 0, err
+	}
+	periods = slices.DeleteFunc(periods, func(period model.BillingPeriodSummary) bool {
+		return (!from.IsZero() && period.From.Before(from)) || (!to.IsZero() && !period.From.Before(to))
+	})
+	total = len(periods)
+	periods = periods[min(offset, len(periods)):]
+	if limit > 0 {
+		periods = periods[:min(limit, len(periods))]
+	}
+	months = []model.BillingMonthSummary{}
+	if len(periods) == 0 {
+		return months, total, nil
+	}
+	// only the trees of the page are loaded
+	latest, err := this.db.GetLatestBillingInformation(timeoutCtx, userId, model.PeriodTypeMonth, periods[0].From, periods[len(periods)-1].To)
+	if err != nil {
+		return months, total, err
+	}
+	for _, period := range periods {
+		month := model.BillingMonthSummary{
+			Month:           period.From.In(this.location).Format("2006-01"),
+			From:            period.From,
+			To:              period.To,
+			Snapshots:       period.Snapshots,
+			LatestCreatedAt: period.LatestCreatedAt,
+		}
+		index := slices.IndexFunc(latest, func(info model.BillingInformation) bool { return info.From.Equal(period.From) })
+		if index >= 0 {
+			info, err := converter.Convert(latest[index])
+			if err != nil {
+				return months, total, err
+			}
+			month.Total = model.TreeTotal(info.Tree)
+			month.Currency = info.Currency
+		}
+		months = append(months, month)
+	}
+	return months, total, nil
+}
+
+// GetLatestBillingInformation returns the newest snapshot of every user and period of the given type with a start in [from, to).
+// An empty userId selects all users.
+func (this *Controller) GetLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time) (trees []model.BillingInformation, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetLatestBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.period_type", periodType),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.to", to.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	return this.db.GetLatestBillingInformation(timeoutCtx, userId, periodType, from, to)
+}
+
+// EachLatestBillingInformation calls f with the newest snapshot of every user and period of the given type with a start in [from, to),
+// read one by one from the database, so they can be streamed without holding all trees in memory. An empty userId selects all users.
+func (this *Controller) EachLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time, f func(info model.BillingInformation) error) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.EachLatestBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.period_type", periodType),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.to", to.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
+	return this.db.EachLatestBillingInformation(ctx, userId, periodType, from, to, f)
+}
+
+// RemoveBillingInformation removes a single snapshot and the cached aggregates containing it.
+func (this *Controller) RemoveBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.RemoveBillingInformation", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.period_type", periodType),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.created_at", createdAt.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
 	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
-	return this.db.ListAvailableBillingInformation(timeoutCtx, userId)
+	err = this.db.RemoveInstance(timeoutCtx, userId, periodType, from, createdAt)
+	if err != nil {
+		return err
+	}
+	if periodType == model.PeriodTypeMonth {
+		return this.db.RemoveAggregates(timeoutCtx, userId, from)
+	}
+	return nil
 }
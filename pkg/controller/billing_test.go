@@ -0,0 +1,77 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestListBillingMonths(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(0), db, events.NewMemory())
+	for month := time.January; month <= time.May; month++ {
+		from := ctrl.MonthStart(2025, month)
+		for i := 1; i <= 2; i++ {
+			err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+				PeriodType: model.PeriodTypeMonth,
+				From:       from,
+				To:         ctrl.MonthStart(2025, month+1),
+				CreatedAt:  from.AddDate(0, 0, i),
+				UserId:     "user-0",
+				Tree:       cpuTree(float64(int(month)*10 + i)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	months, total, err := ctrl.ListBillingMonths(context.Background(), "user-0", ctrl.MonthStart(2025, time.February), ctrl.MonthStart(2025, time.May), 2, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(months) != 2 {
+		t.Fatalf("expected 2 of 3 months, got %v of %v: %#v", len(months), total, months)
+	}
+	for i, expected := range []string{"2025-03", "2025-04"} {
+		month := months[i]
+		if month.Month != expected || month.Snapshots != 2 || month.Total != float64((i+3)*10+2) || !month.LatestCreatedAt.Equal(month.From.AddDate(0, 0, 2)) {
+			t.Errorf("unexpected month %#v", month)
+		}
+	}
+
+	months, total, err = ctrl.ListBillingMonths(context.Background(), "user-0", time.Time{}, time.Time{}, 0, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 || len(months) != 0 {
+		t.Errorf("expected empty page after the last month, got %v of %v", len(months), total)
+	}
+
+	_, _, err = ctrl.ListBillingMonths(context.Background(), "user-0", time.Time{}, time.Time{}, 0, -1, "")
+	if err == nil {
+		t.Error("expected error for negative offset")
+	}
+}
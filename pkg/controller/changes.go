@@ -0,0 +1,89 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetBillingChanges compares the snapshots of the period valid at since and at until, the newest snapshot created at or before each instant.
+// Users whose tree hash differs, or who only have a snapshot at one of the instants, are reported.
+func (this *Controller) GetBillingChanges(ctx context.Context, periodType model.PeriodType, from time.Time, since time.Time, until time.Time) (report model.BillingChangeReport, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.GetBillingChanges", trace.WithAttributes(
+		attribute.String("billing.period_type", periodType),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.since", since.Format(time.RFC3339)),
+		attribute.String("billing.until", until.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
+	if until.Before(since) {
+		return report, errors.Join(model.ErrBadRequest, errors.New("until has to be after since"))
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	report = model.BillingChangeReport{
+		PeriodType: periodType,
+		From:       from,
+		Since:      since,
+		Until:      until,
+		Changes:    []model.BillingChange{},
+	}
+	// snapshots are read sorted by user and creation time, so only the snapshots of one user are held at a time
+	user := []model.Snapshot{}
+	compare := func() {
+		if len(user) == 0 {
+			return
+		}
+		old := snapshotAt(user, since)
+		current := snapshotAt(user, until)
+		changed := (old == nil) != (current == nil) || (old != nil && old.Hash != current.Hash)
+		if changed {
+			report.Changes = append(report.Changes, model.BillingChange{UserId: user[0].UserId, Old: old, New: current})
+		}
+	}
+	err = this.db.EachSnapshot(timeoutCtx, periodType, from, func(snapshot model.Snapshot) error {
+		if len(user) > 0 && user[0].UserId != snapshot.UserId {
+			compare()
+			user = []model.Snapshot{}
+		}
+		user = append(user, snapshot)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	compare()
+	return report, nil
+}
+
+// snapshotAt returns the newest of the snapshots, sorted by creation time, created at or before t.
+func snapshotAt(snapshots []model.Snapshot, t time.Time) (result *model.Snapshot) {
+	for i := range snapshots {
+		if snapshots[i].CreatedAt.After(t) {
+			break
+		}
+		result = &snapshots[i]
+	}
+	return result
+}
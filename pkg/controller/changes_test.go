@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestGetBillingChanges(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(0), db, events.NewMemory())
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC)
+	}
+	store := func(userId string, createdAt time.Time, tree calcmodel.CostTree) {
+		err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+			PeriodType: model.PeriodTypeMonth,
+			From:       from,
+			To:         time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			CreatedAt:  createdAt,
+			UserId:     userId,
+			Tree:       tree,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// unchanged, only stored once
+	store("unchanged", day(2), cpuTree(1))
+	// same tree stored again
+	store("restored", day(2), cpuTree(1))
+	store("restored", day(5), cpuTree(1))
+	store("changed", day(2), cpuTree(1))
+	store("changed", day(5), cpuTree(2))
+	// changed after the second instant
+	store("later", day(2), cpuTree(1))
+	store("later", day(20), cpuTree(2))
+	store("new", day(5), cpuTree(1))
+
+	report, err := ctrl.GetBillingChanges(context.Background(), model.PeriodTypeMonth, from, day(3), day(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %#v", report.Changes)
+	}
+	changed, added := report.Changes[0], report.Changes[1]
+	if changed.UserId != "changed" || changed.Old == nil || changed.New == nil || !changed.Old.CreatedAt.Equal(day(2)) || !changed.New.CreatedAt.Equal(day(5)) {
+		t.Errorf("unexpected change %#v", changed)
+	}
+	oldHash, err := model.TreeHash(cpuTree(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newHash, err := model.TreeHash(cpuTree(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Old.Hash != oldHash || changed.New.Hash != newHash {
+		t.Errorf("unexpected hashes %v %v", changed.Old.Hash, changed.New.Hash)
+	}
+	if added.UserId != "new" || added.Old != nil || added.New == nil {
+		t.Errorf("unexpected change %#v", added)
+	}
+
+	_, err = ctrl.GetBillingChanges(context.Background(), model.PeriodTypeMonth, from, day(10), day(3))
+	if err == nil {
+		t.Error("expected error for until before since")
+	}
+}
@@ -18,31 +18,62 @@ package controller
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	gocloak "github.com/Nerzal/gocloak/v13"
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
 	"github.com/SENERGY-Platform/billing/pkg/configuration"
 	"github.com/SENERGY-Platform/billing/pkg/database"
-	"github.com/SENERGY-Platform/cost-calculator/pkg/client"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
 )
 
+// Keycloak is the subset of *gocloak.GoCloak used to list the users to bill.
+type Keycloak interface {
+	LoginClient(ctx context.Context, clientID, clientSecret, realm string, scopes ...string) (*gocloak.JWT, error)
+	GetUsers(ctx context.Context, accessToken, realm string, params gocloak.GetUsersParams) ([]*gocloak.User, error)
+	GetUserByID(ctx context.Context, accessToken, realm, userID string) (*gocloak.User, error)
+}
+
 type Controller struct {
-	calc           client.Client
+	calc           calculator.Client
 	config         configuration.Config
-	keycloakClient *gocloak.GoCloak
-	db             *database.Mongo
+	keycloakClient Keycloak
+	db             database.Database
+	publisher      events.Publisher
+	metrics        *metrics.Metrics
+	location       *time.Location
+	ctx            context.Context
+	wg             *sync.WaitGroup
 }
 
-func NewController(ctx context.Context, conf configuration.Config, fatal func(err error), db *database.Mongo) *Controller {
-	calc := client.New(conf.CalculatorUrl)
+func NewController(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config, fatal func(err error), db database.Database, publisher events.Publisher, metrics *metrics.Metrics) (*Controller, error) {
+	calc := calculator.New(conf.CalculatorUrl)
 
 	keycloakClient := gocloak.NewClient(conf.KeycloakUrl)
+	keycloakClient.RestyClient().SetTransport(tracing.Transport())
 
+	return NewControllerWithClients(ctx, wg, conf, fatal, db, publisher, metrics, calc, keycloakClient)
+}
+
+func NewControllerWithClients(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config, fatal func(err error), db database.Database, publisher events.Publisher, metrics *metrics.Metrics, calc calculator.Client, keycloakClient Keycloak) (*Controller, error) {
+	location, err := time.LoadLocation(conf.BillingTimeZone)
+	if err != nil {
+		return nil, err
+	}
 	controller := &Controller{
 		calc:           calc,
 		config:         conf,
 		db:             db,
 		keycloakClient: keycloakClient,
+		publisher:      publisher,
+		metrics:        metrics,
+		location:       location,
+		ctx:            ctx,
+		wg:             wg,
 	}
 
-	return controller
+	return controller, nil
 }
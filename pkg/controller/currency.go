@@ -0,0 +1,111 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Currency returns the configured currency of the cost calculator values.
+func (this *Controller) Currency() string {
+	return this.config.Currency
+}
+
+// ListExchangeRates returns the exchange rate table, sorted by currencies and validity.
+func (this *Controller) ListExchangeRates(ctx context.Context) (rates model.ExchangeRates, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ListExchangeRates")
+	defer func() { tracing.End(span, err) }()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return this.db.ListExchangeRates(timeoutCtx)
+}
+
+// SetExchangeRate stores the rate, replacing the rate of the same currencies valid from the same instant.
+func (this *Controller) SetExchangeRate(ctx context.Context, rate model.ExchangeRate) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.SetExchangeRate", trace.WithAttributes(
+		attribute.String("billing.currency_from", rate.From),
+		attribute.String("billing.currency_to", rate.To),
+	))
+	defer func() { tracing.End(span, err) }()
+	err = rate.Validate()
+	if err != nil {
+		return errors.Join(model.ErrBadRequest, err)
+	}
+	rate.ValidFrom = rate.ValidFrom.UTC()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return this.db.SetExchangeRate(timeoutCtx, rate)
+}
+
+// RemoveExchangeRate removes the rate of the currencies valid from the given instant.
+func (this *Controller) RemoveExchangeRate(ctx context.Context, from string, to string, validFrom time.Time) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.RemoveExchangeRate", trace.WithAttributes(
+		attribute.String("billing.currency_from", from),
+		attribute.String("billing.currency_to", to),
+	))
+	defer func() { tracing.End(span, err) }()
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return this.db.RemoveExchangeRate(timeoutCtx, from, to, validFrom.UTC())
+}
+
+// CurrencyConverter converts billing information into a requested currency, see Controller.CurrencyConverter.
+type CurrencyConverter struct {
+	currency        string
+	defaultCurrency string
+	rates           model.ExchangeRates
+}
+
+// CurrencyConverter loads the exchange rates needed to convert billing information into currency.
+// An empty currency keeps the amounts in the currency they were stored in.
+func (this *Controller) CurrencyConverter(ctx context.Context, currency string) (converter *CurrencyConverter, err error) {
+	converter = &CurrencyConverter{currency: currency, defaultCurrency: this.config.Currency}
+	if currency == "" {
+		return converter, nil
+	}
+	if !model.ValidCurrency(currency) {
+		return converter, errors.Join(model.ErrBadRequest, errors.New("expected currency as ISO 4217 code like EUR, got "+currency))
+	}
+	converter.rates, err = this.ListExchangeRates(ctx)
+	return converter, err
+}
+
+// Convert converts the tree of info with the exchange rate valid at the start of its period.
+// Snapshots stored before currencies were recorded are treated as stored in the configured currency.
+func (this *CurrencyConverter) Convert(info model.BillingInformation) (model.BillingInformation, error) {
+	return this.rates.ConvertBillingInformation(info, this.currency, this.defaultCurrency)
+}
+
+// ConvertAll converts every snapshot, see Convert.
+func (this *CurrencyConverter) ConvertAll(infos []model.BillingInformation) (result []model.BillingInformation, err error) {
+	result = make([]model.BillingInformation, 0, len(infos))
+	for _, info := range infos {
+		info, err = this.Convert(info)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
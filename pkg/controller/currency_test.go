@@ -0,0 +1,93 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestCurrencyConversion(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemory()
+	ctrl := newTestControllerWithConfig(t, &configuration.ConfigStruct{Currency: "EUR"}, nil, nil, db, events.NewMemory())
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, info := range []model.BillingInformation{
+		// stored before currencies were recorded
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 1), To: ctrl.MonthStart(2024, 2), CreatedAt: created, Tree: cpuTree(10)},
+		{UserId: "a", PeriodType: model.PeriodTypeMonth, From: ctrl.MonthStart(2024, 3), To: ctrl.MonthStart(2024, 4), CreatedAt: created, Currency: "EUR", Tree: cpuTree(20)},
+	} {
+		err := db.SetBillingInformation(ctx, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rate := range []model.ExchangeRate{
+		{From: "EUR", To: "CHF", ValidFrom: ctrl.MonthStart(2024, 1), Rate: 0.5},
+		{From: "EUR", To: "CHF", ValidFrom: ctrl.MonthStart(2024, 2), Rate: 2},
+	} {
+		err := ctrl.SetExchangeRate(ctx, rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// every month is converted with the rate valid at its start
+	aggregate, err := ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "CHF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregate.Currency != "CHF" || aggregate.Tree["analytics"].Month.Cpu != 45 {
+		t.Errorf("expected 45 CHF, got %v %v", aggregate.Tree["analytics"].Month.Cpu, aggregate.Currency)
+	}
+	aggregate, err = ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregate.Currency != "EUR" || aggregate.Tree["analytics"].Month.Cpu != 30 {
+		t.Errorf("expected 30 EUR, got %v %v", aggregate.Tree["analytics"].Month.Cpu, aggregate.Currency)
+	}
+
+	months, _, err := ctrl.ListBillingMonths(ctx, "a", time.Time{}, time.Time{}, 0, 0, "CHF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 2 || months[0].Total != 5 || months[1].Total != 40 || months[0].Currency != "CHF" {
+		t.Errorf("unexpected months %#v", months)
+	}
+
+	_, err = ctrl.GetYearlyAggregatedBillingInformation(ctx, "a", 2024, "USD")
+	if !errors.Is(err, model.ErrBadRequest) {
+		t.Errorf("expected bad request for missing exchange rate, got %v", err)
+	}
+	_, err = ctrl.CurrencyConverter(ctx, "chf")
+	if !errors.Is(err, model.ErrBadRequest) {
+		t.Errorf("expected bad request for invalid currency, got %v", err)
+	}
+	err = ctrl.SetExchangeRate(ctx, model.ExchangeRate{From: "EUR", To: "CHF", ValidFrom: created, Rate: -1})
+	if !errors.Is(err, model.ErrBadRequest) {
+		t.Errorf("expected bad request for invalid rate, got %v", err)
+	}
+}
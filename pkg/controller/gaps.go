@@ -0,0 +1,128 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FindGaps lists the months in [from, to) since the user's creation without billing information, of all users if userId is empty.
+func (c *Controller) FindGaps(ctx context.Context, userId string, from time.Time, to time.Time) (report model.GapReport, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.FindGaps", trace.WithAttributes(
+		attribute.String("billing.user_id", userId),
+		attribute.String("billing.from", from.Format(time.RFC3339)),
+		attribute.String("billing.to", to.Format(time.RFC3339)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	periods, err := c.PeriodsBetween(model.PeriodTypeMonth, from, to)
+	if err != nil {
+		return report, errors.Join(model.ErrBadRequest, err)
+	}
+	now := time.Now()
+	periods = slices.DeleteFunc(periods, func(period model.Period) bool {
+		return period.From.After(now)
+	})
+
+	report = model.GapReport{From: from, To: to, Gaps: []model.BillingGap{}}
+	report.Users, err = c.eachUser(ctx, userId, func(_ string, user *gocloak.User) error {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		available, err := c.db.ListAvailableBillingInformation(timeoutCtx, *user.ID, model.PeriodTypeMonth)
+		if err != nil {
+			return err
+		}
+		for _, period := range periods {
+			if user.CreatedTimestamp != nil && !period.To.After(time.UnixMilli(*user.CreatedTimestamp)) {
+				continue
+			}
+			if !slices.ContainsFunc(available, func(summary model.BillingPeriodSummary) bool { return summary.From.Equal(period.From) }) {
+				report.Gaps = append(report.Gaps, model.BillingGap{UserId: *user.ID, Period: period})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	span.SetAttributes(attribute.Int("billing.gaps", len(report.Gaps)))
+	return report, nil
+}
+
+// gapsPerLogin is the number of gaps filled with one keycloak access token.
+const gapsPerLogin = 50
+
+// FillGaps stores the billing information of every gap as a single billing run.
+func (c *Controller) FillGaps(ctx context.Context, gaps []model.BillingGap) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.FillGaps", trace.WithAttributes(attribute.Int("billing.gaps", len(gaps))))
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now().UTC()
+	run := model.BillingRunCompletedEvent{RunId: runId(ctx), StartedAt: now}
+	periods := []model.Period{}
+	users := map[string]bool{}
+	var token string
+	for i, gap := range gaps {
+		// like eachUser per page of users, a fresh token per batch keeps long fills from outliving it
+		if i%gapsPerLogin == 0 {
+			jwt, err := c.keycloakClient.LoginClient(ctx, c.config.KeycloakClient, c.config.KeycloakSecret, "master")
+			if err != nil {
+				c.metrics.KeycloakErrors.Inc()
+				return err
+			}
+			token = jwt.AccessToken
+		}
+		tree, err := c.fetchTree(ctx, token, gap.UserId, gap.Period)
+		if err != nil {
+			return err
+		}
+		stored, err := c.storeTree(ctx, run.RunId, gap.UserId, gap.Period, now, tree)
+		if err != nil {
+			return err
+		}
+		if stored {
+			run.Trees++
+		} else {
+			run.Unchanged++
+		}
+		users[gap.UserId] = true
+		if !slices.ContainsFunc(periods, func(period model.Period) bool {
+			return period.Type == gap.Period.Type && period.From.Equal(gap.Period.From)
+		}) {
+			periods = append(periods, gap.Period)
+		}
+	}
+	for _, period := range periods {
+		if period.Type == model.PeriodTypeMonth {
+			run.Months++
+		}
+	}
+	run.Periods = len(periods)
+	run.Users = len(users)
+	c.metrics.UsersProcessed.Add(float64(run.Users))
+	c.completeRun(ctx, span, run, periods)
+	return nil
+}
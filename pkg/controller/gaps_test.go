@@ -0,0 +1,139 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestFindAndFillGaps(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	keycloak := newFakeKeycloak(2)
+	created := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC).UnixMilli()
+	keycloak.users[0].CreatedTimestamp = &created
+	ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, db, events.NewMemory())
+
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+		PeriodType: model.PeriodTypeMonth,
+		From:       february,
+		To:         time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		UserId:     "user-1",
+		Tree:       cpuTree(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	report, err := ctrl.FindGaps(context.Background(), "", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		userId string
+		month  time.Month
+	}{
+		{"user-0", time.February},
+		{"user-0", time.March},
+		{"user-1", time.January},
+		{"user-1", time.March},
+	}
+	if report.Users != 2 || len(report.Gaps) != len(expected) {
+		t.Fatalf("unexpected report %#v", report)
+	}
+	for i, gap := range report.Gaps {
+		if gap.UserId != expected[i].userId || gap.Period.From.Month() != expected[i].month || gap.Period.Type != model.PeriodTypeMonth {
+			t.Errorf("gap %v: expected %v %v, got %v %v", i, expected[i].userId, expected[i].month, gap.UserId, gap.Period.From)
+		}
+	}
+
+	logins := keycloak.logins
+	err = ctrl.FillGaps(context.Background(), report.Gaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keycloak.logins != logins+1 {
+		t.Errorf("expected a single keycloak login for less than %v gaps, got %v", gapsPerLogin, keycloak.logins-logins)
+	}
+	if len(calc.Requests()) != len(expected) {
+		t.Errorf("expected %v calculator requests, got %v", len(expected), len(calc.Requests()))
+	}
+	report, err = ctrl.FindGaps(context.Background(), "", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Gaps) != 0 {
+		t.Errorf("expected no gaps after filling them, got %#v", report.Gaps)
+	}
+}
+
+func TestFindGapsOfSingleUser(t *testing.T) {
+	keycloak := newFakeKeycloak(1)
+	created := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC).UnixMilli()
+	keycloak.users[0].CreatedTimestamp = &created
+	ctrl := newTestController(t, nil, keycloak, database.NewMemory(), events.NewMemory())
+
+	report, err := ctrl.FindGaps(context.Background(), "user-0", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// months ending before the user was created are not reported
+	if report.Users != 1 || len(report.Gaps) != 2 || report.Gaps[0].Period.From.Month() != time.March || report.Gaps[1].Period.From.Month() != time.April {
+		t.Errorf("unexpected report %#v", report)
+	}
+
+	_, err = ctrl.FindGaps(context.Background(), "unknown", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("expected not found for unknown user, got %v", err)
+	}
+}
+
+func TestFillGapsLogsInPerBatch(t *testing.T) {
+	calc := newFakeCalculator(t)
+	keycloak := newFakeKeycloak(1)
+	ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, database.NewMemory(), events.NewMemory())
+	periods, err := ctrl.PeriodsBetween(model.PeriodTypeWeek, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) <= gapsPerLogin {
+		t.Fatalf("expected more than %v periods, got %v", gapsPerLogin, len(periods))
+	}
+	gaps := []model.BillingGap{}
+	for _, period := range periods {
+		gaps = append(gaps, model.BillingGap{UserId: "user-0", Period: period})
+	}
+	err = ctrl.FillGaps(context.Background(), gaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keycloak.logins != 2 {
+		t.Errorf("expected a keycloak login per %v gaps, got %v logins for %v gaps", gapsPerLogin, keycloak.logins, len(gaps))
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+var healthClient = &http.Client{Transport: tracing.Transport()}
+
+// Readiness checks the database and, if enabled in the config, the cost calculator and keycloak concurrently.
+func (this *Controller) Readiness(ctx context.Context) model.HealthReport {
+	ctx, span := tracing.Start(ctx, "Controller.Readiness")
+	defer span.End()
+	checks := map[string]func(ctx context.Context) error{
+		"database": this.db.Ping,
+	}
+	if this.config.HealthCheckCalculator {
+		checks["calculator"] = func(ctx context.Context) error {
+			return checkHttp(ctx, this.config.CalculatorUrl)
+		}
+	}
+	if this.config.HealthCheckKeycloak {
+		checks["keycloak"] = func(ctx context.Context) error {
+			return checkHttp(ctx, strings.TrimSuffix(this.config.KeycloakUrl, "/")+"/realms/master")
+		}
+	}
+
+	report := model.HealthReport{Status: model.HealthStatusUp, Dependencies: map[string]model.DependencyHealth{}}
+	mux := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			timeoutCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := check(timeoutCtx)
+			health := model.DependencyHealth{Status: model.HealthStatusUp, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				health.Status = model.HealthStatusDown
+				health.Error = err.Error()
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			report.Dependencies[name] = health
+			if err != nil {
+				report.Status = model.HealthStatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// checkHttp treats every response without server error as reachable.
+func checkHttp(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected statuscode %v", resp.StatusCode)
+	}
+	return nil
+}
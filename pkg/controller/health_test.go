@@ -0,0 +1,64 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestReadiness(t *testing.T) {
+	calc := newFakeCalculator(t)
+	keycloak := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer keycloak.Close()
+
+	config := &configuration.ConfigStruct{CalculatorUrl: calc.server.URL, KeycloakUrl: keycloak.URL}
+	ctrl := newTestControllerWithConfig(t, config, nil, nil, database.NewMemory(), events.NewMemory())
+
+	report := ctrl.Readiness(context.Background())
+	if report.Status != model.HealthStatusUp || len(report.Dependencies) != 1 {
+		t.Errorf("expected only the database to be checked by default, got %#v", report)
+	}
+
+	config.HealthCheckCalculator = true
+	config.HealthCheckKeycloak = true
+	report = ctrl.Readiness(context.Background())
+	if report.Status != model.HealthStatusDown {
+		t.Errorf("expected down status, got %#v", report)
+	}
+	for name, expected := range map[string]model.HealthStatus{
+		"database":   model.HealthStatusUp,
+		"calculator": model.HealthStatusUp,
+		"keycloak":   model.HealthStatusDown,
+	} {
+		if report.Dependencies[name].Status != expected {
+			t.Errorf("expected %v to be %v, got %#v", name, expected, report.Dependencies[name])
+		}
+	}
+	if report.Dependencies["keycloak"].Error == "" {
+		t.Error("expected error message for keycloak")
+	}
+}
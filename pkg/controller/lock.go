@@ -0,0 +1,195 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const jobLockName = "billing-job"
+
+const defaultJobLockTtl = 2 * time.Minute
+
+// ErrJobLocked is returned if another instance holds the job lease.
+var ErrJobLocked = errors.Join(model.ErrConflict, errors.New("another billing job is running"))
+
+// ErrJobLockLost is the cause of the context of a JobLock, if the lease could not be renewed in time.
+var ErrJobLockLost = errors.New("billing job lease lost")
+
+// JobLock is a lease on the billing job or the database migrations, kept alive by a heartbeat until Release is called.
+type JobLock struct {
+	controller *Controller
+	name       string
+	owner      string
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func (c *Controller) jobLockTtl() time.Duration {
+	ttl, err := time.ParseDuration(c.config.JobLockTtl)
+	if err != nil || ttl <= 0 {
+		return defaultJobLockTtl
+	}
+	return ttl
+}
+
+// LockJob acquires the lease on the billing job. If another instance holds it, LockJob either waits
+// until the lease is released or expired, or returns ErrJobLocked.
+func (c *Controller) LockJob(ctx context.Context, wait bool) (*JobLock, error) {
+	return c.lock(ctx, jobLockName, wait)
+}
+
+// lock acquires the lease with the given name, see LockJob.
+func (c *Controller) lock(ctx context.Context, name string, wait bool) (*JobLock, error) {
+	hostname, _ := os.Hostname()
+	owner := hostname + "/" + uuid.NewString()
+	ttl := c.jobLockTtl()
+	for {
+		acquired, err := c.tryLock(ctx, name, owner, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		if !wait {
+			return nil, ErrJobLocked
+		}
+		log.Logger.Info("lease is held by another instance, waiting", "lease", name)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(ttl / 3):
+		}
+	}
+	lock := &JobLock{
+		controller: c,
+		name:       name,
+		owner:      owner,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	lock.ctx, lock.cancel = context.WithCancelCause(ctx)
+	go lock.heartbeat(ttl)
+	return lock, nil
+}
+
+// RunLocked runs f while holding the lease on the billing job. If another instance holds the lease,
+// it waits for it or returns ErrJobLocked, depending on the job_lock_wait config.
+// The context passed to f is canceled with ErrJobLockLost if the lease can not be renewed.
+func (c *Controller) RunLocked(ctx context.Context, f func(ctx context.Context) error) error {
+	lock, err := c.LockJob(ctx, c.config.JobLockWait)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	err = f(lock.Context())
+	if cause := context.Cause(lock.Context()); errors.Is(cause, ErrJobLockLost) {
+		return errors.Join(cause, err)
+	}
+	return err
+}
+
+// StartLocked acquires the lease on the billing job without waiting and runs f in the background while holding it.
+// The run is derived from the application context instead of ctx, so it outlives the request starting it,
+// is canceled on shutdown and is waited for before the service exits. Only the run id and the trace of ctx are kept.
+// An error returned by f is logged with the name of the job.
+func (c *Controller) StartLocked(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	runCtx := trace.ContextWithSpanContext(c.ctx, trace.SpanContextFromContext(ctx))
+	if runId, ok := ctx.Value(runIdContextKey{}).(string); ok {
+		runCtx = c.WithRunId(runCtx, runId)
+	}
+	lock, err := c.LockJob(runCtx, false)
+	if err != nil {
+		return err
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer lock.Release()
+		err := f(lock.Context())
+		if err != nil {
+			log.Logger.Error(name+" failed", attributes.ErrorKey, err)
+		}
+	}()
+	return nil
+}
+
+// Context is canceled with ErrJobLockLost if the lease could not be renewed before it expired.
+func (this *JobLock) Context() context.Context {
+	return this.ctx
+}
+
+// Release stops the heartbeat and releases the lease.
+func (this *JobLock) Release() {
+	close(this.stop)
+	<-this.done
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(this.ctx), 10*time.Second)
+	defer cancel()
+	err := this.controller.db.Unlock(ctx, this.name, this.owner)
+	if err != nil {
+		log.Logger.Error("unable to release lease", "lease", this.name, attributes.ErrorKey, err)
+	}
+	this.cancel(context.Canceled)
+}
+
+// heartbeat renews the lease every third of its ttl. Single failed renewals are tolerated as long as the lease has not expired.
+func (this *JobLock) heartbeat(ttl time.Duration) {
+	defer close(this.done)
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-this.stop:
+			return
+		case <-this.ctx.Done():
+			return
+		case <-ticker.C:
+			acquired, err := this.controller.tryLock(this.ctx, this.name, this.owner, ttl)
+			if err != nil {
+				log.Logger.Error("unable to renew lease", "lease", this.name, attributes.ErrorKey, err)
+			}
+			if err == nil && acquired {
+				renewed = time.Now()
+				continue
+			}
+			if (err == nil && !acquired) || time.Since(renewed) >= ttl {
+				log.Logger.Error("lease lost, canceling its holder", "lease", this.name)
+				this.cancel(ErrJobLockLost)
+				return
+			}
+		}
+	}
+}
+
+func (c *Controller) tryLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, min(ttl/3, 10*time.Second))
+	defer cancel()
+	return c.db.TryLock(timeoutCtx, name, owner, ttl)
+}
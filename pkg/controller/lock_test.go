@@ -0,0 +1,180 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
+)
+
+// stolenLockDatabase fails to renew leases after the first acquisition, as if another instance took over.
+type stolenLockDatabase struct {
+	*database.Memory
+	calls atomic.Int32
+}
+
+func (this *stolenLockDatabase) TryLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	if this.calls.Add(1) > 1 {
+		return false, nil
+	}
+	return this.Memory.TryLock(ctx, name, owner, ttl)
+}
+
+func TestLockJob(t *testing.T) {
+	db := database.NewMemory()
+	config := &configuration.ConfigStruct{JobLockTtl: "300ms"}
+	first := newTestControllerWithConfig(t, config, nil, nil, db, events.NewMemory())
+	second := newTestControllerWithConfig(t, config, nil, nil, db, events.NewMemory())
+
+	lock, err := first.LockJob(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// outlive the ttl to check the heartbeat keeps the lease
+	time.Sleep(500 * time.Millisecond)
+	_, err = second.LockJob(context.Background(), false)
+	if !errors.Is(err, ErrJobLocked) {
+		t.Errorf("expected ErrJobLocked, got %v", err)
+	}
+	err = second.RunLocked(context.Background(), func(ctx context.Context) error {
+		t.Error("RunLocked must not run f while the lease is held by another controller")
+		return nil
+	})
+	if !errors.Is(err, ErrJobLocked) {
+		t.Errorf("expected ErrJobLocked, got %v", err)
+	}
+	if lock.Context().Err() != nil {
+		t.Errorf("expected lease to be kept, got %v", context.Cause(lock.Context()))
+	}
+
+	waiting := newTestControllerWithConfig(t, &configuration.ConfigStruct{JobLockTtl: "300ms", JobLockWait: true}, nil, nil, db, events.NewMemory())
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lock.Release()
+	}()
+	ran := false
+	err = waiting.RunLocked(context.Background(), func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("expected waiting controller to run after release, got %v", err)
+	}
+
+	lock, err = second.LockJob(context.Background(), false)
+	if err != nil {
+		t.Fatalf("expected released lease to be available, got %v", err)
+	}
+	lock.Release()
+}
+
+func TestLockJobLost(t *testing.T) {
+	db := &stolenLockDatabase{Memory: database.NewMemory()}
+	ctrl := newTestControllerWithConfig(t, &configuration.ConfigStruct{JobLockTtl: "90ms"}, nil, nil, db, events.NewMemory())
+	err := ctrl.RunLocked(context.Background(), func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			t.Error("expected context to be canceled after losing the lease")
+			return nil
+		}
+	})
+	if !errors.Is(err, ErrJobLockLost) {
+		t.Errorf("expected ErrJobLockLost, got %v", err)
+	}
+}
+
+func TestStartLocked(t *testing.T) {
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	ctrl, err := NewControllerWithClients(appCtx, wg, &configuration.ConfigStruct{}, func(err error) {}, database.NewMemory(), events.NewMemory(), metrics.New(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestCtx, endRequest := context.WithCancel(ctrl.WithRunId(context.Background(), "run-1"))
+	started := make(chan string)
+	var canceled atomic.Bool
+	err = ctrl.StartLocked(requestCtx, "test job", func(ctx context.Context) error {
+		started <- runId(ctx)
+		<-ctx.Done()
+		canceled.Store(true)
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := <-started; id != "run-1" {
+		t.Errorf("expected run id of the request, got %v", id)
+	}
+	endRequest()
+
+	err = ctrl.StartLocked(context.Background(), "second job", func(ctx context.Context) error {
+		t.Error("StartLocked must not run f while the lease is held")
+		return nil
+	})
+	if !errors.Is(err, ErrJobLocked) {
+		t.Errorf("expected ErrJobLocked, got %v", err)
+	}
+
+	// the end of the request does not cancel the run, the shutdown does and waits for it
+	time.Sleep(50 * time.Millisecond)
+	if canceled.Load() {
+		t.Error("expected run to outlive the request")
+	}
+	cancel()
+	wg.Wait()
+	if !canceled.Load() {
+		t.Error("expected shutdown to wait for the canceled run")
+	}
+}
+
+func TestMigrateWaitsForMigrationLease(t *testing.T) {
+	db := database.NewMemory()
+	config := &configuration.ConfigStruct{JobLockTtl: "300ms"}
+	first := newTestControllerWithConfig(t, config, nil, nil, db, events.NewMemory())
+	second := newTestControllerWithConfig(t, config, nil, nil, db, events.NewMemory())
+
+	lock, err := first.lock(context.Background(), migrationLockName, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// outlive the ttl to check the heartbeat keeps the lease of long migrations
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = second.Migrate(timeoutCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Migrate to wait for the lease, got %v", err)
+	}
+
+	lock.Release()
+	_, err = second.Migrate(context.Background())
+	if err != nil {
+		t.Errorf("expected Migrate to run after release, got %v", err)
+	}
+}
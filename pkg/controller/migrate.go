@@ -0,0 +1,74 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+)
+
+// Migrations lists the data migrations of the database and when they were applied.
+func (this *Controller) Migrations(ctx context.Context) (migrations []model.Migration, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.Migrations")
+	defer func() { tracing.End(span, err) }()
+	return this.db.Migrations(ctx)
+}
+
+const migrationLockName = "billing-migrations"
+
+// Migrate applies the pending data migrations of the database while holding the migration lease.
+// Concurrent calls of other instances wait for it. The lease is renewed like the job lease,
+// so it is kept for migrations of any length and expires soon after a crashed instance stopped renewing it.
+func (this *Controller) Migrate(ctx context.Context) (applied []model.Migration, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.Migrate")
+	defer func() { tracing.End(span, err) }()
+	lock, err := this.lock(ctx, migrationLockName, true)
+	if err != nil {
+		return applied, err
+	}
+	defer lock.Release()
+	applied, err = this.db.Migrate(lock.Context())
+	if cause := context.Cause(lock.Context()); errors.Is(cause, ErrJobLockLost) {
+		return applied, errors.Join(cause, err)
+	}
+	return applied, err
+}
+
+// MigrateOnStartup applies the pending data migrations if migrate_on_startup is set, otherwise it only warns about them.
+func (this *Controller) MigrateOnStartup(ctx context.Context) error {
+	if this.config.MigrateOnStartup {
+		_, err := this.Migrate(ctx)
+		return err
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	migrations, err := this.db.Migrations(timeoutCtx)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			log.Logger.Warn("pending database migration, run the migrate command", "version", m.Version, "description", m.Description)
+		}
+	}
+	return nil
+}
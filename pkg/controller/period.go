@@ -0,0 +1,148 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	_ "time/tzdata" // the alpine image ships without zoneinfo
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+// MonthStart returns the first instant of the calendar month in the billing time zone.
+// Month values outside 1-12 are normalized, so month 0 is December of the previous year.
+func (this *Controller) MonthStart(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, this.location).UTC()
+}
+
+// DayStart returns the first instant of the day in the billing time zone.
+func (this *Controller) DayStart(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, this.location).UTC()
+}
+
+// Periods returns the n completed periods of the given type before now, newest first.
+func (this *Controller) Periods(periodType model.PeriodType, now time.Time, n int) (periods []model.Period, err error) {
+	var bounds func(t time.Time, before int) (time.Time, time.Time)
+	switch periodType {
+	case model.PeriodTypeMonth:
+		bounds = monthBounds
+	case model.PeriodTypeQuarter:
+		bounds = quarterBounds
+	case model.PeriodTypeWeek:
+		bounds = weekBounds
+	default:
+		return nil, errors.New("unable to generate periods of type '" + periodType + "'")
+	}
+	localNow := now.In(this.location)
+	for i := 1; i <= n; i++ {
+		from, to := bounds(localNow, i)
+		periods = append(periods, model.Period{Type: periodType, From: from, To: to})
+	}
+	return periods, nil
+}
+
+// MaxPeriodsBetween is the largest number of periods PeriodsBetween returns, it limits the ranges of triggered billing runs and gap checks.
+// It covers five years of weeks and more than twenty years of months.
+const MaxPeriodsBetween = 260
+
+// PeriodsBetween returns all periods of the given type starting in [from, to), oldest first.
+// For custom periods it returns the single period between the start of the from day and the start of the to day.
+// Ranges with more than MaxPeriodsBetween periods are rejected.
+func (this *Controller) PeriodsBetween(periodType model.PeriodType, from time.Time, to time.Time) (periods []model.Period, err error) {
+	var bounds func(t time.Time, before int) (time.Time, time.Time)
+	switch periodType {
+	case model.PeriodTypeMonth:
+		bounds = monthBounds
+	case model.PeriodTypeQuarter:
+		bounds = quarterBounds
+	case model.PeriodTypeWeek:
+		bounds = weekBounds
+	case model.PeriodTypeCustom:
+		period, err := this.CustomPeriod(from.In(this.location), to.In(this.location))
+		if err != nil {
+			return nil, err
+		}
+		return []model.Period{period}, nil
+	default:
+		return nil, errors.New("unable to generate periods of type '" + periodType + "'")
+	}
+	if !from.Before(to) {
+		return nil, errors.New("range has to end after its start")
+	}
+	localTo := to.In(this.location)
+	for i := 0; ; i++ {
+		periodFrom, periodTo := bounds(localTo, i)
+		if periodFrom.Before(from) {
+			break
+		}
+		if periodFrom.Before(to) {
+			if len(periods) == MaxPeriodsBetween {
+				return nil, fmt.Errorf("range covers more than %v periods", MaxPeriodsBetween)
+			}
+			periods = append(periods, model.Period{Type: periodType, From: periodFrom, To: periodTo})
+		}
+	}
+	slices.Reverse(periods)
+	return periods, nil
+}
+
+// CustomPeriod returns the period between the start of the from day and the start of the to day in the billing time zone.
+func (this *Controller) CustomPeriod(from time.Time, to time.Time) (model.Period, error) {
+	period := model.Period{
+		Type: model.PeriodTypeCustom,
+		From: this.DayStart(from.Year(), from.Month(), from.Day()),
+		To:   this.DayStart(to.Year(), to.Month(), to.Day()),
+	}
+	if !period.From.Before(period.To) {
+		return period, errors.New("custom period has to end after its start")
+	}
+	return period, nil
+}
+
+// monthBounds returns the calendar month lying monthsBefore months before the month of t,
+// using the location of t for the month boundaries.
+func monthBounds(t time.Time, monthsBefore int) (from time.Time, to time.Time) {
+	to = time.Date(t.Year(), t.Month()-time.Month(monthsBefore-1), 1, 0, 0, 0, 0, t.Location())
+	from = time.Date(t.Year(), t.Month()-time.Month(monthsBefore), 1, 0, 0, 0, 0, t.Location())
+	return from.UTC(), to.UTC()
+}
+
+// quarterBounds returns the calendar quarter lying quartersBefore quarters before the quarter of t,
+// using the location of t for the quarter boundaries.
+func quarterBounds(t time.Time, quartersBefore int) (from time.Time, to time.Time) {
+	quarterStart := time.Month((int(t.Month())-1)/3*3 + 1)
+	to = time.Date(t.Year(), quarterStart-time.Month(3*(quartersBefore-1)), 1, 0, 0, 0, 0, t.Location())
+	from = time.Date(t.Year(), quarterStart-time.Month(3*quartersBefore), 1, 0, 0, 0, 0, t.Location())
+	return from.UTC(), to.UTC()
+}
+
+// weekBounds returns the ISO week (starting on monday) lying weeksBefore weeks before the week of t,
+// using the location of t for the week boundaries.
+func weekBounds(t time.Time, weeksBefore int) (from time.Time, to time.Time) {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	to = time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday-7*(weeksBefore-1), 0, 0, 0, 0, t.Location())
+	from = time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday-7*weeksBefore, 0, 0, 0, 0, t.Location())
+	return from.UTC(), to.UTC()
+}
+
+// Location returns the time zone used for period boundaries.
+func (this *Controller) Location() *time.Location {
+	return this.location
+}
@@ -0,0 +1,225 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestMonthBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name         string
+		now          time.Time
+		monthsBefore int
+		from         string
+		to           string
+	}{
+		{"utc", time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 1, "2025-05-01T00:00:00Z", "2025-06-01T00:00:00Z"},
+		{"utc year change", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), 1, "2024-12-01T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"utc two years back", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 14, "2023-12-01T00:00:00Z", "2024-01-01T00:00:00Z"},
+		{"berlin year change", time.Date(2025, 1, 15, 12, 0, 0, 0, berlin), 1, "2024-11-30T23:00:00Z", "2024-12-31T23:00:00Z"},
+		{"berlin dst start", time.Date(2025, 4, 10, 12, 0, 0, 0, berlin), 1, "2025-02-28T23:00:00Z", "2025-03-31T22:00:00Z"},
+		// 2025-01-01T00:30 in Berlin is still 2024-12-31 in UTC
+		{"berlin new year night", time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC).In(berlin), 1, "2024-11-30T23:00:00Z", "2024-12-31T23:00:00Z"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to := monthBounds(c.now, c.monthsBefore)
+			if from.Format(time.RFC3339) != c.from || to.Format(time.RFC3339) != c.to {
+				t.Errorf("expected %v - %v, got %v - %v", c.from, c.to, from.Format(time.RFC3339), to.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestQuarterBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name           string
+		now            time.Time
+		quartersBefore int
+		from           string
+		to             string
+	}{
+		{"first day of quarter", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), 1, "2025-01-01T00:00:00Z", "2025-04-01T00:00:00Z"},
+		{"last day of quarter", time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC), 1, "2025-01-01T00:00:00Z", "2025-04-01T00:00:00Z"},
+		{"year change", time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), 1, "2024-10-01T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"five quarters back", time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), 5, "2023-10-01T00:00:00Z", "2024-01-01T00:00:00Z"},
+		{"berlin", time.Date(2025, 11, 2, 0, 0, 0, 0, berlin), 1, "2025-06-30T22:00:00Z", "2025-09-30T22:00:00Z"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to := quarterBounds(c.now, c.quartersBefore)
+			if from.Format(time.RFC3339) != c.from || to.Format(time.RFC3339) != c.to {
+				t.Errorf("expected %v - %v, got %v - %v", c.from, c.to, from.Format(time.RFC3339), to.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestWeekBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name        string
+		now         time.Time
+		weeksBefore int
+		from        string
+		to          string
+	}{
+		{"monday", time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC), 1, "2025-06-09T00:00:00Z", "2025-06-16T00:00:00Z"},
+		{"sunday", time.Date(2025, 6, 22, 23, 0, 0, 0, time.UTC), 1, "2025-06-09T00:00:00Z", "2025-06-16T00:00:00Z"},
+		{"year change", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), 1, "2024-12-23T00:00:00Z", "2024-12-30T00:00:00Z"},
+		{"two weeks back", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), 2, "2024-12-16T00:00:00Z", "2024-12-23T00:00:00Z"},
+		{"berlin dst end", time.Date(2025, 10, 28, 12, 0, 0, 0, berlin), 1, "2025-10-19T22:00:00Z", "2025-10-26T23:00:00Z"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to := weekBounds(c.now, c.weeksBefore)
+			if from.Format(time.RFC3339) != c.from || to.Format(time.RFC3339) != c.to {
+				t.Errorf("expected %v - %v, got %v - %v", c.from, c.to, from.Format(time.RFC3339), to.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestJobPeriods(t *testing.T) {
+	config := &configuration.ConfigStruct{
+		BillingTimeZone: "Europe/Berlin",
+		JobMonths:       2,
+		JobQuarters:     1,
+		JobWeeks:        3,
+		JobCustomFrom:   "2025-01-15",
+		JobCustomTo:     "2025-02-15",
+	}
+	ctrl := newTestControllerWithConfig(t, config, nil, nil, database.NewMemory(), events.NewMemory())
+	periods, err := ctrl.JobPeriods(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []model.Period{
+		{Type: model.PeriodTypeMonth, From: time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeMonth, From: time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeQuarter, From: time.Date(2024, 9, 30, 22, 0, 0, 0, time.UTC), To: time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeWeek, From: time.Date(2025, 3, 2, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 3, 9, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeWeek, From: time.Date(2025, 2, 23, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 3, 2, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeWeek, From: time.Date(2025, 2, 16, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 2, 23, 23, 0, 0, 0, time.UTC)},
+		{Type: model.PeriodTypeCustom, From: time.Date(2025, 1, 14, 23, 0, 0, 0, time.UTC), To: time.Date(2025, 2, 14, 23, 0, 0, 0, time.UTC)},
+	}
+	if len(periods) != len(expected) {
+		t.Fatalf("expected %v periods, got %#v", len(expected), periods)
+	}
+	for i := range expected {
+		if periods[i].Type != expected[i].Type || !periods[i].From.Equal(expected[i].From) || !periods[i].To.Equal(expected[i].To) {
+			t.Errorf("period %v: expected %v, got %v", i, expected[i], periods[i])
+		}
+	}
+
+	_, err = ctrl.CustomPeriod(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Error("expected error for empty custom period")
+	}
+}
+
+func TestPeriodsBetween(t *testing.T) {
+	config := &configuration.ConfigStruct{BillingTimeZone: "Europe/Berlin"}
+	ctrl := newTestControllerWithConfig(t, config, nil, nil, database.NewMemory(), events.NewMemory())
+	cases := []struct {
+		name       string
+		periodType model.PeriodType
+		from       time.Time
+		to         time.Time
+		expected   []string
+	}{
+		{"months", model.PeriodTypeMonth, ctrl.DayStart(2024, 11, 1), ctrl.DayStart(2025, 2, 1), []string{"2024-10-31T23:00:00Z", "2024-11-30T23:00:00Z", "2024-12-31T23:00:00Z"}},
+		{"months starting within range", model.PeriodTypeMonth, ctrl.DayStart(2024, 11, 15), ctrl.DayStart(2025, 1, 15), []string{"2024-11-30T23:00:00Z", "2024-12-31T23:00:00Z"}},
+		{"quarters", model.PeriodTypeQuarter, ctrl.DayStart(2024, 1, 1), ctrl.DayStart(2024, 7, 1), []string{"2023-12-31T23:00:00Z", "2024-03-31T22:00:00Z"}},
+		{"weeks", model.PeriodTypeWeek, ctrl.DayStart(2025, 3, 1), ctrl.DayStart(2025, 3, 17), []string{"2025-03-02T23:00:00Z", "2025-03-09T23:00:00Z"}},
+		{"custom", model.PeriodTypeCustom, ctrl.DayStart(2025, 1, 15), ctrl.DayStart(2025, 2, 15), []string{"2025-01-14T23:00:00Z"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			periods, err := ctrl.PeriodsBetween(c.periodType, c.from, c.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []string{}
+			for _, period := range periods {
+				if period.Type != c.periodType {
+					t.Errorf("expected period type %v, got %v", c.periodType, period.Type)
+				}
+				actual = append(actual, period.From.Format(time.RFC3339))
+			}
+			if !slices.Equal(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+
+	_, err := ctrl.PeriodsBetween(model.PeriodTypeMonth, ctrl.DayStart(2025, 2, 1), ctrl.DayStart(2025, 1, 1))
+	if err == nil {
+		t.Error("expected error for inverted range")
+	}
+}
+
+func TestMonthStartMatchesStoredFrom(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	config := &configuration.ConfigStruct{BillingTimeZone: "Europe/Berlin"}
+	ctrl := newTestControllerWithConfig(t, config, calculator.New(calc.server.URL), newFakeKeycloak(1), db, events.NewMemory())
+
+	err := ctrl.StoreMonthlyBillingInformation(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localNow := time.Now().In(ctrl.location)
+	for i := 1; i <= 2; i++ {
+		month := time.Date(localNow.Year(), localNow.Month()-time.Month(i), 1, 0, 0, 0, 0, ctrl.location)
+		infos, err := ctrl.GetMonthlyBillingInformation(context.Background(), "user-0", month.Year(), month.Month())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != 1 {
+			t.Fatalf("expected one billing information for %v, got %v", month, len(infos))
+		}
+		if !infos[0].From.Equal(month) {
+			t.Errorf("expected from %v, got %v", month, infos[0].From)
+		}
+		if infos[0].TimeZone != "Europe/Berlin" {
+			t.Errorf("unexpected time zone %q", infos[0].TimeZone)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RetentionModeDelete = "delete"
+const RetentionModeArchive = "archive"
+
+// RetentionEnabled reports if a retention policy is configured.
+func (this *Controller) RetentionEnabled() bool {
+	return this.config.RetentionKeepProvisional > 0
+}
+
+// ApplyRetention removes the snapshots exceeding the retention policy: final snapshots are kept forever,
+// of the provisional snapshots only the newest retention_keep_provisional per user and period are kept.
+// Depending on retention_mode the others are deleted or moved to the archive. With dryRun, nothing is removed.
+// The snapshots are streamed from the database and handled one user and period at a time.
+// ApplyRetention does not take the job lock, callers should hold it.
+func (this *Controller) ApplyRetention(ctx context.Context, dryRun bool) (report model.RetentionReport, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ApplyRetention", trace.WithAttributes(
+		attribute.String("billing.retention.mode", this.config.RetentionMode),
+		attribute.Bool("billing.dry_run", dryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+	if !this.RetentionEnabled() {
+		return report, errors.Join(model.ErrBadRequest, errors.New("retention is disabled, set retention_keep_provisional"))
+	}
+	report = model.RetentionReport{
+		StartedAt: time.Now().UTC(),
+		Mode:      this.config.RetentionMode,
+		DryRun:    dryRun,
+	}
+	// snapshots are sorted by user, period and creation time, so a period is complete once the next one starts
+	period := []model.Snapshot{}
+	applyToPeriod := func() error {
+		if len(period) == 0 {
+			return nil
+		}
+		expired := expiredSnapshots(period, this.config.RetentionKeepProvisional)
+		report.Periods++
+		report.Kept += len(period) - len(expired)
+		for _, snapshot := range expired {
+			if !dryRun {
+				err := this.removeSnapshot(ctx, snapshot)
+				if err != nil {
+					return err
+				}
+				this.metrics.SnapshotsRemoved.WithLabelValues(this.config.RetentionMode).Inc()
+			}
+			report.Removed++
+		}
+		period = period[:0]
+		return nil
+	}
+	err = this.db.EachAllSnapshots(ctx, func(snapshot model.Snapshot) error {
+		if len(period) > 0 && !samePeriod(period[0], snapshot) {
+			err := applyToPeriod()
+			if err != nil {
+				return err
+			}
+		}
+		period = append(period, snapshot)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	err = applyToPeriod()
+	if err != nil {
+		return report, err
+	}
+	report.CompletedAt = time.Now().UTC()
+	if !dryRun {
+		this.metrics.LastRetentionRun.Set(float64(report.CompletedAt.Unix()))
+	}
+	log.Logger.Info("retention applied", "mode", report.Mode, "dry_run", dryRun, "periods", report.Periods, "kept", report.Kept, "removed", report.Removed)
+	return report, nil
+}
+
+func (this *Controller) removeSnapshot(ctx context.Context, snapshot model.Snapshot) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if this.config.RetentionMode == RetentionModeArchive {
+		return this.db.ArchiveInstance(timeoutCtx, snapshot.UserId, snapshot.PeriodType, snapshot.From, snapshot.CreatedAt)
+	}
+	return this.db.RemoveInstance(timeoutCtx, snapshot.UserId, snapshot.PeriodType, snapshot.From, snapshot.CreatedAt)
+}
+
+func samePeriod(a model.Snapshot, b model.Snapshot) bool {
+	return a.UserId == b.UserId && a.PeriodType == b.PeriodType && a.From.Equal(b.From)
+}
+
+// expiredSnapshots returns the provisional snapshots, sorted by creation time, older than the newest keepProvisional ones.
+func expiredSnapshots(snapshots []model.Snapshot, keepProvisional int) (expired []model.Snapshot) {
+	provisional := 0
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].IsFinal() {
+			continue
+		}
+		provisional++
+		if provisional > keepProvisional {
+			expired = append(expired, snapshots[i])
+		}
+	}
+	return expired
+}
+
+// StartRetentionSchedule applies the retention policy every retention_interval until ctx is done.
+// Runs are skipped while a billing job holds the job lock.
+func (this *Controller) StartRetentionSchedule(ctx context.Context, wg *sync.WaitGroup) error {
+	interval, err := time.ParseDuration(this.config.RetentionInterval)
+	if err != nil {
+		return err
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				lock, err := this.LockJob(ctx, false)
+				if errors.Is(err, ErrJobLocked) {
+					log.Logger.Info("billing job is running, skipping retention")
+					continue
+				}
+				if err != nil {
+					log.Logger.Error("unable to lock retention", attributes.ErrorKey, err)
+					continue
+				}
+				_, err = this.ApplyRetention(lock.Context(), false)
+				lock.Release()
+				if err != nil {
+					log.Logger.Error("unable to apply retention", attributes.ErrorKey, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+func TestApplyRetention(t *testing.T) {
+	for _, mode := range []string{RetentionModeDelete, RetentionModeArchive} {
+		t.Run(mode, func(t *testing.T) {
+			calc := newFakeCalculator(t)
+			db := database.NewMemory()
+			config := &configuration.ConfigStruct{KeycloakClient: "billing", KeycloakSecret: "secret", RetentionKeepProvisional: 2, RetentionMode: mode}
+			ctrl := newTestControllerWithConfig(t, config, calculator.New(calc.server.URL), newFakeKeycloak(0), db, events.NewMemory())
+
+			from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+			to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+			store := func(userId string, createdAt time.Time) {
+				err := db.SetBillingInformation(context.Background(), model.BillingInformation{PeriodType: model.PeriodTypeMonth, From: from, To: to, CreatedAt: createdAt, UserId: userId, Tree: cpuTree(float64(createdAt.Day()))})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			// 4 provisional and 2 final snapshots, the 2 oldest provisional ones expire
+			for day := 1; day <= 4; day++ {
+				store("a", from.AddDate(0, 0, day))
+			}
+			store("a", to)
+			store("a", to.AddDate(0, 0, 1))
+			// within the limit
+			store("b", from.AddDate(0, 0, 1))
+			store("b", from.AddDate(0, 0, 2))
+
+			report, err := ctrl.ApplyRetention(context.Background(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Removed != 2 || report.Kept != 6 || report.Periods != 2 {
+				t.Fatalf("unexpected dry run report %#v", report)
+			}
+			snapshots, _ := db.ListAllSnapshots(context.Background())
+			if len(snapshots) != 8 {
+				t.Fatalf("dry run should not remove snapshots, got %v", len(snapshots))
+			}
+
+			report, err = ctrl.ApplyRetention(context.Background(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Removed != 2 || report.Kept != 6 {
+				t.Fatalf("unexpected report %#v", report)
+			}
+			snapshots, _ = db.ListAllSnapshots(context.Background())
+			if len(snapshots) != 6 {
+				t.Errorf("expected 6 remaining snapshots, got %v", len(snapshots))
+			}
+			// the oldest provisional snapshots of a are removed
+			for _, snapshot := range snapshots {
+				if snapshot.UserId == "a" && snapshot.CreatedAt.Before(from.AddDate(0, 0, 3)) {
+					t.Errorf("expected removed snapshot, got %#v", snapshot)
+				}
+			}
+			archived := len(db.Archived())
+			if (mode == RetentionModeArchive && archived != 2) || (mode == RetentionModeDelete && archived != 0) {
+				t.Errorf("unexpected number of archived snapshots %v", archived)
+			}
+
+			report, err = ctrl.ApplyRetention(context.Background(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Removed != 0 {
+				t.Errorf("second run should not remove anything, got %v", report.Removed)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionDisabled(t *testing.T) {
+	calc := newFakeCalculator(t)
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(0), database.NewMemory(), events.NewMemory())
+	_, err := ctrl.ApplyRetention(context.Background(), true)
+	if err == nil {
+		t.Error("expected error if retention is disabled")
+	}
+}
@@ -18,17 +18,352 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"slices"
 
 	"time"
 
 	"github.com/Nerzal/gocloak/v13"
 	"github.com/SENERGY-Platform/billing/pkg/log"
 	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (c *Controller) StoreMonthlyBillingInformation(ctx context.Context, nMonths int) error {
+	periods, err := c.Periods(model.PeriodTypeMonth, time.Now(), nMonths)
+	if err != nil {
+		return err
+	}
+	return c.StoreBillingInformation(ctx, periods)
+}
+
+// JobPeriods returns all periods configured for the billing job.
+func (c *Controller) JobPeriods(now time.Time) (periods []model.Period, err error) {
+	for _, job := range []struct {
+		periodType model.PeriodType
+		n          int
+	}{
+		{model.PeriodTypeMonth, c.config.JobMonths},
+		{model.PeriodTypeQuarter, c.config.JobQuarters},
+		{model.PeriodTypeWeek, c.config.JobWeeks},
+	} {
+		typePeriods, err := c.Periods(job.periodType, now, job.n)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, typePeriods...)
+	}
+	if c.config.JobCustomFrom != "" || c.config.JobCustomTo != "" {
+		from, err := time.Parse(time.DateOnly, c.config.JobCustomFrom)
+		if err != nil {
+			return nil, err
+		}
+		to, err := time.Parse(time.DateOnly, c.config.JobCustomTo)
+		if err != nil {
+			return nil, err
+		}
+		period, err := c.CustomPeriod(from, to)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+// BillingRunPeriods returns the periods of the given type starting in the requested range,
+// or the periods configured for the billing job if the request has no range.
+func (c *Controller) BillingRunPeriods(request model.BillingRunRequest, now time.Time) (periods []model.Period, err error) {
+	if request.From == "" && request.To == "" {
+		return c.JobPeriods(now)
+	}
+	if request.From == "" || request.To == "" {
+		return nil, errors.Join(model.ErrBadRequest, errors.New("from and to have to be set together"))
+	}
+	periodType := request.PeriodType
+	if periodType == "" {
+		periodType = model.PeriodTypeMonth
+	}
+	if !model.IsValidPeriodType(periodType) {
+		return nil, errors.Join(model.ErrBadRequest, errors.New("invalid period type '"+periodType+"'"))
+	}
+	from, err := time.Parse(time.DateOnly, request.From)
+	if err != nil {
+		return nil, errors.Join(model.ErrBadRequest, err)
+	}
+	to, err := time.Parse(time.DateOnly, request.To)
+	if err != nil {
+		return nil, errors.Join(model.ErrBadRequest, err)
+	}
+	periods, err = c.PeriodsBetween(periodType, c.DayStart(from.Year(), from.Month(), from.Day()), c.DayStart(to.Year(), to.Month(), to.Day()))
+	if err != nil {
+		return nil, errors.Join(model.ErrBadRequest, err)
+	}
+	return periods, nil
+}
+
+func (c *Controller) StoreBillingInformation(ctx context.Context, periods []model.Period) (err error) {
+	return c.StoreBillingInformationForUser(ctx, "", periods)
+}
+
+// StoreBillingInformationForUser stores the billing information of the given periods for a single user.
+// An empty userId stores the billing information of all keycloak users.
+func (c *Controller) StoreBillingInformationForUser(ctx context.Context, userId string, periods []model.Period) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.StoreBillingInformation", trace.WithAttributes(
+		attribute.Int("billing.periods", len(periods)),
+		attribute.String("billing.user_id", userId),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now().UTC()
+
+	run := model.BillingRunCompletedEvent{RunId: runId(ctx), StartedAt: now, Periods: len(periods)}
+	for _, period := range periods {
+		if period.Type == model.PeriodTypeMonth {
+			run.Months++
+		}
+	}
+
+	run.Users, err = c.eachUser(ctx, userId, func(token string, user *gocloak.User) error {
+		userId := *user.ID
+		for _, period := range periods {
+			tree, err := c.fetchTree(ctx, token, userId, period)
+			if err != nil {
+				return err
+			}
+			stored, err := c.storeTree(ctx, run.RunId, userId, period, now, tree)
+			if err != nil {
+				return err
+			}
+			if stored {
+				run.Trees++
+			} else {
+				run.Unchanged++
+			}
+		}
+		c.metrics.UsersProcessed.Inc()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.completeRun(ctx, span, run, periods)
+	return nil
+}
+
+// BackfillBillingInformation stores the given periods one after another and reports the progress after every period.
+// Users already having a final snapshot of a period are skipped, unless force is set.
+// An empty userId backfills all keycloak users.
+func (c *Controller) BackfillBillingInformation(ctx context.Context, userId string, periods []model.Period, force bool, progress func(model.BackfillProgress)) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.BackfillBillingInformation", trace.WithAttributes(
+		attribute.Int("billing.periods", len(periods)),
+		attribute.String("billing.user_id", userId),
+		attribute.Bool("billing.force", force),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	now := time.Now().UTC()
 
+	run := model.BillingRunCompletedEvent{RunId: runId(ctx), StartedAt: now, Periods: len(periods)}
+	users := map[string]bool{}
+	for index, period := range periods {
+		if period.Type == model.PeriodTypeMonth {
+			run.Months++
+		}
+		current := model.BackfillProgress{Period: period, Index: index + 1, Total: len(periods)}
+		_, err = c.eachUser(ctx, userId, func(token string, user *gocloak.User) error {
+			userId := *user.ID
+			users[userId] = true
+			if !force {
+				final, err := c.hasFinalSnapshot(ctx, userId, period)
+				if err != nil {
+					return err
+				}
+				if final {
+					current.Skipped++
+					return nil
+				}
+			}
+			tree, err := c.fetchTree(ctx, token, userId, period)
+			if err != nil {
+				return err
+			}
+			stored, err := c.storeTree(ctx, run.RunId, userId, period, now, tree)
+			if err != nil {
+				return err
+			}
+			if stored {
+				current.Stored++
+				run.Trees++
+			} else {
+				current.Unchanged++
+				run.Unchanged++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		log.Logger.Info("backfilled period", "period_type", period.Type, "from", period.From.Format(time.RFC3339), "index", current.Index, "total", current.Total, "stored", current.Stored, "skipped", current.Skipped, "unchanged", current.Unchanged)
+		if progress != nil {
+			progress(current)
+		}
+	}
+	run.Users = len(users)
+	c.metrics.UsersProcessed.Add(float64(run.Users))
+
+	c.completeRun(ctx, span, run, periods)
+	return nil
+}
+
+func (c *Controller) hasFinalSnapshot(ctx context.Context, userId string, period model.Period) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	stored, err := c.db.GetBillingInformation(timeoutCtx, userId, period.Type, period.From)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(stored, model.BillingInformation.IsFinal), nil
+}
+
+// storeTree stores the tree as snapshot of the period created at now, invalidates affected aggregates and publishes the snapshot.
+// The tree is not stored if it equals the latest stored snapshot, unless only the new snapshot would be final.
+func (c *Controller) storeTree(ctx context.Context, runId string, userId string, period model.Period, now time.Time, tree calcmodel.CostTree) (stored bool, err error) {
+	hash, err := model.TreeHash(tree)
+	if err != nil {
+		return false, err
+	}
+	billingInformation := model.BillingInformation{PeriodType: period.Type, From: period.From, UserId: userId, To: period.To, CreatedAt: now, RunId: runId, TimeZone: c.location.String(), Hash: hash, Currency: c.config.Currency, Tree: tree}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	existing, err := c.db.GetBillingInformation(timeoutCtx, userId, period.Type, period.From)
+	if err != nil {
+		return false, err
+	}
+	latest, found := latestSnapshot(existing)
+	var latestHash string
+	if found {
+		latestHash, err = latest.TreeHash()
+		if err != nil {
+			return false, err
+		}
+	}
+	if found && (latest.IsFinal() || !billingInformation.IsFinal()) && latestHash == billingInformation.Hash {
+		log.Logger.Info("tree unchanged, skip store", "user_id", userId, "run_id", latest.RunId)
+		c.metrics.TreesUnchanged.Inc()
+		return false, nil
+	}
+	log.Logger.Info("store tree", "user_id", userId, "run_id", runId)
+	err = c.db.SetBillingInformation(timeoutCtx, billingInformation)
+	if err == nil && period.Type == model.PeriodTypeMonth {
+		err = c.db.RemoveAggregates(timeoutCtx, userId, period.From)
+	}
+	if err != nil {
+		return false, err
+	}
+	c.metrics.TreesStored.Inc()
+	err = c.publisher.PublishBillingInformationCreated(ctx, billingInformation)
+	if err != nil {
+		log.Logger.Error("unable to publish billing information created event", "user_id", userId, attributes.ErrorKey, err)
+	}
+	return true, nil
+}
+
+func latestSnapshot(infos []model.BillingInformation) (latest model.BillingInformation, found bool) {
+	for _, info := range infos {
+		if !found || info.CreatedAt.After(latest.CreatedAt) {
+			latest = info
+			found = true
+		}
+	}
+	return latest, found
+}
+
+type runIdContextKey struct{}
+
+// WithRunId sets the id used by billing runs started with the returned context.
+// Retrying a run with the same id replaces the snapshots of the first attempt instead of adding new ones.
+func (c *Controller) WithRunId(ctx context.Context, runId string) context.Context {
+	return context.WithValue(ctx, runIdContextKey{}, runId)
+}
+
+// runId returns the run id set by WithRunId or a new random id.
+func runId(ctx context.Context) string {
+	if runId, ok := ctx.Value(runIdContextKey{}).(string); ok && runId != "" {
+		return runId
+	}
+	return uuid.NewString()
+}
+
+// completeRun records the metrics of a successful run and publishes its completion.
+func (c *Controller) completeRun(ctx context.Context, span trace.Span, run model.BillingRunCompletedEvent, periods []model.Period) {
+	span.SetAttributes(attribute.String("billing.run_id", run.RunId), attribute.Int("billing.users", run.Users), attribute.Int("billing.trees", run.Trees), attribute.Int("billing.unchanged", run.Unchanged))
+	run.CompletedAt = time.Now().UTC()
+	c.metrics.RunDuration.Observe(run.CompletedAt.Sub(run.StartedAt).Seconds())
+	for _, period := range periods {
+		c.metrics.LastSuccessfulRuns.WithLabelValues(period.Type).Set(float64(run.CompletedAt.Unix()))
+		c.metrics.BilledPeriod(period.Type, period.To)
+	}
+	publishErr := c.publisher.PublishBillingRunCompleted(ctx, run)
+	if publishErr != nil {
+		log.Logger.Error("unable to publish billing run completed event", attributes.ErrorKey, publishErr)
+	}
+}
+
+// DryRunBillingInformation fetches the trees of the given periods like StoreBillingInformationForUser
+// and compares their totals with the latest stored snapshots. Nothing is stored or published.
+// An empty userId compares the billing information of all keycloak users.
+func (c *Controller) DryRunBillingInformation(ctx context.Context, userId string, periods []model.Period) (report model.DryRunReport, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.DryRunBillingInformation", trace.WithAttributes(
+		attribute.Int("billing.periods", len(periods)),
+		attribute.String("billing.user_id", userId),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	report = model.DryRunReport{StartedAt: time.Now().UTC(), Entries: []model.DryRunEntry{}}
+	_, err = c.eachUser(ctx, userId, func(token string, user *gocloak.User) error {
+		userId := *user.ID
+		for _, period := range periods {
+			tree, err := c.fetchTree(ctx, token, userId, period)
+			if err != nil {
+				return err
+			}
+			timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			stored, err := c.db.GetBillingInformation(timeoutCtx, userId, period.Type, period.From)
+			cancel()
+			if err != nil {
+				return err
+			}
+			entry := model.DryRunEntry{UserId: userId, PeriodType: period.Type, From: period.From, To: period.To, NewTotal: model.TreeTotal(tree)}
+			for _, info := range stored {
+				if entry.StoredAt == nil || info.CreatedAt.After(*entry.StoredAt) {
+					entry.StoredAt = &info.CreatedAt
+					entry.OldTotal = model.TreeTotal(info.Tree)
+				}
+			}
+			entry.Delta = entry.NewTotal - entry.OldTotal
+			report.Entries = append(report.Entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	report.CompletedAt = time.Now().UTC()
+	return report, nil
+}
+
+// eachUser calls f for every keycloak user, or only for userId if it is not empty, with an access token
+// of a login per page of 50 users.
+// It returns the number of users passed to f.
+func (c *Controller) eachUser(ctx context.Context, userId string, f func(token string, user *gocloak.User) error) (count int, err error) {
 	usersOffset := 0
 	userLimit := 50
 	hasMoreUsers := true
@@ -36,40 +371,55 @@ func (c *Controller) StoreMonthlyBillingInformation(ctx context.Context, nMonths
 	for hasMoreUsers {
 		jwt, err := c.keycloakClient.LoginClient(ctx, c.config.KeycloakClient, c.config.KeycloakSecret, "master")
 		if err != nil {
-			return err
+			c.metrics.KeycloakErrors.Inc()
+			return count, err
 		}
 
-		users, err := c.keycloakClient.GetUsers(ctx, jwt.AccessToken, "master", gocloak.GetUsersParams{
-			First: &usersOffset,
-			Max:   &userLimit,
-		})
-		if err != nil {
-			return err
+		var users []*gocloak.User
+		if userId != "" {
+			// the full user is needed for its creation date
+			user, err := c.keycloakClient.GetUserByID(ctx, jwt.AccessToken, "master", userId)
+			var apiErr *gocloak.APIError
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+				return count, errors.Join(model.ErrNotFound, errors.New("unknown user "+userId))
+			}
+			if err != nil {
+				c.metrics.KeycloakErrors.Inc()
+				return count, err
+			}
+			users = []*gocloak.User{user}
+			hasMoreUsers = false
+		} else {
+			users, err = c.keycloakClient.GetUsers(ctx, jwt.AccessToken, "master", gocloak.GetUsersParams{
+				First: &usersOffset,
+				Max:   &userLimit,
+			})
+			if err != nil {
+				c.metrics.KeycloakErrors.Inc()
+				return count, err
+			}
+			usersOffset += len(users)
+			hasMoreUsers = len(users) == userLimit
 		}
-		usersOffset += len(users)
-		hasMoreUsers = len(users) == userLimit
 
 		for _, user := range users {
-			for i := 1; i <= nMonths; i++ {
-				to := time.Date(now.Year(), now.Month()-time.Month(i-1), 1, 0, 0, 0, 0, time.UTC)
-				from := time.Date(now.Year(), now.Month()-time.Month(i), 1, 0, 0, 0, 0, time.UTC)
-
-				log.Logger.Info("fetch monthly billing information", "from", from.Format(time.RFC3339), "to", to.Format(time.RFC3339))
-				tree, err := c.calc.GetTree("Bearer "+jwt.AccessToken, true, &from, &to, user.ID)
-				if err != nil {
-					return err
-				}
-				log.Logger.Info("store tree", "user_id", *user.ID)
-				billingInformation := model.BillingInformation{From: from, UserId: *user.ID, To: to, CreatedAt: now, Tree: tree}
-				timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-				err = c.db.SetBillingInformation(timeoutCtx, billingInformation)
-				cancel()
-				if err != nil {
-					return err
-				}
+			err = f(jwt.AccessToken, user)
+			if err != nil {
+				return count, err
 			}
+			count++
 		}
 	}
+	return count, nil
+}
 
-	return nil
+func (c *Controller) fetchTree(ctx context.Context, token string, userId string, period model.Period) (tree calcmodel.CostTree, err error) {
+	log.Logger.Info("fetch billing information", "period_type", period.Type, "from", period.From.Format(time.RFC3339), "to", period.To.Format(time.RFC3339))
+	calcStart := time.Now()
+	tree, err = c.calc.GetTree(ctx, "Bearer "+token, true, &period.From, &period.To, &userId)
+	c.metrics.CalculatorLatency.Observe(time.Since(calcStart).Seconds())
+	if err != nil {
+		c.metrics.CalculatorErrors.Inc()
+	}
+	return tree, err
 }
@@ -0,0 +1,611 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/SENERGY-Platform/billing/pkg/calculator"
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMain(m *testing.M) {
+	log.InitForTest()
+	os.Exit(m.Run())
+}
+
+type calculatorRequest struct {
+	Token   string
+	Start   time.Time
+	End     time.Time
+	ForUser string
+}
+
+type fakeCalculator struct {
+	server     *httptest.Server
+	mux        sync.Mutex
+	requests   []calculatorRequest
+	statusCode int
+}
+
+// newFakeCalculator starts a cost calculator answering every /tree request with a tree
+// containing the requested user and the start of the requested period.
+func newFakeCalculator(t *testing.T) *fakeCalculator {
+	calc := &fakeCalculator{statusCode: http.StatusOK}
+	calc.server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/tree" {
+			http.Error(writer, "unexpected path", http.StatusNotFound)
+			return
+		}
+		start, err := time.Parse(time.RFC3339, request.URL.Query().Get("start"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		end, err := time.Parse(time.RFC3339, request.URL.Query().Get("end"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		calc.mux.Lock()
+		calc.requests = append(calc.requests, calculatorRequest{
+			Token:   request.Header.Get("Authorization"),
+			Start:   start,
+			End:     end,
+			ForUser: request.URL.Query().Get("for_user"),
+		})
+		statusCode := calc.statusCode
+		calc.mux.Unlock()
+		if statusCode != http.StatusOK {
+			http.Error(writer, "calculator error", statusCode)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(calcmodel.CostTree{
+			request.URL.Query().Get("for_user"): {
+				CostWithEstimation: calcmodel.CostWithEstimation{
+					Month: calcmodel.CostEntry{Cpu: float64(start.Month())},
+				},
+			},
+		})
+	}))
+	t.Cleanup(calc.server.Close)
+	return calc
+}
+
+func (this *fakeCalculator) Requests() []calculatorRequest {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return append([]calculatorRequest{}, this.requests...)
+}
+
+type fakeKeycloak struct {
+	users       []*gocloak.User
+	loginErr    error
+	getUsersErr error
+	mux         sync.Mutex
+	pageSizes   []int
+	logins      int
+}
+
+func newFakeKeycloak(n int) *fakeKeycloak {
+	users := []*gocloak.User{}
+	for i := 0; i < n; i++ {
+		users = append(users, &gocloak.User{ID: gocloak.StringP("user-" + strconv.Itoa(i))})
+	}
+	return &fakeKeycloak{users: users}
+}
+
+func (this *fakeKeycloak) LoginClient(_ context.Context, _, _, _ string, _ ...string) (*gocloak.JWT, error) {
+	if this.loginErr != nil {
+		return nil, this.loginErr
+	}
+	this.mux.Lock()
+	this.logins++
+	this.mux.Unlock()
+	return &gocloak.JWT{AccessToken: "token"}, nil
+}
+
+func (this *fakeKeycloak) GetUsers(_ context.Context, accessToken, _ string, params gocloak.GetUsersParams) ([]*gocloak.User, error) {
+	if this.getUsersErr != nil {
+		return nil, this.getUsersErr
+	}
+	if accessToken != "token" {
+		return nil, errors.New("unexpected access token")
+	}
+	first := min(*params.First, len(this.users))
+	last := min(first+*params.Max, len(this.users))
+	this.mux.Lock()
+	this.pageSizes = append(this.pageSizes, last-first)
+	this.mux.Unlock()
+	return this.users[first:last], nil
+}
+
+func (this *fakeKeycloak) GetUserByID(_ context.Context, accessToken, _ string, userID string) (*gocloak.User, error) {
+	if accessToken != "token" {
+		return nil, errors.New("unexpected access token")
+	}
+	for _, user := range this.users {
+		if *user.ID == userID {
+			return user, nil
+		}
+	}
+	return nil, &gocloak.APIError{Code: http.StatusNotFound, Message: "user not found"}
+}
+
+type failingDatabase struct {
+	*database.Memory
+	err error
+}
+
+func (this failingDatabase) SetBillingInformation(context.Context, model.BillingInformation) error {
+	return this.err
+}
+
+func newTestController(t *testing.T, calc calculator.Client, keycloak Keycloak, db database.Database, publisher events.Publisher) *Controller {
+	return newTestControllerWithConfig(t, &configuration.ConfigStruct{KeycloakClient: "billing", KeycloakSecret: "secret"}, calc, keycloak, db, publisher)
+}
+
+func newTestControllerWithConfig(t *testing.T, config configuration.Config, calc calculator.Client, keycloak Keycloak, db database.Database, publisher events.Publisher) *Controller {
+	ctrl, err := NewControllerWithClients(context.Background(), &sync.WaitGroup{}, config, func(err error) {}, db, publisher, metrics.New(), calc, keycloak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctrl
+}
+
+func TestStoreMonthlyBillingInformationMonthBoundaries(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	publisher := events.NewMemory()
+	config := &configuration.ConfigStruct{KeycloakClient: "billing", KeycloakSecret: "secret", Currency: "EUR"}
+	ctrl := newTestControllerWithConfig(t, config, calculator.New(calc.server.URL), newFakeKeycloak(1), db, publisher)
+
+	// 13 months always include a change of year
+	nMonths := 13
+	err := ctrl.StoreMonthlyBillingInformation(context.Background(), nMonths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	expectedTo := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	requests := calc.Requests()
+	if len(requests) != nMonths {
+		t.Fatalf("expected %v calculator requests, got %v", nMonths, len(requests))
+	}
+	for i, request := range requests {
+		expectedFrom := expectedTo.AddDate(0, -1, 0)
+		if !request.Start.Equal(expectedFrom) || !request.End.Equal(expectedTo) {
+			t.Errorf("request %v: expected %v - %v, got %v - %v", i, expectedFrom, expectedTo, request.Start, request.End)
+		}
+		if request.Token != "Bearer token" {
+			t.Errorf("request %v: unexpected token %q", i, request.Token)
+		}
+		if request.ForUser != "user-0" {
+			t.Errorf("request %v: unexpected user %q", i, request.ForUser)
+		}
+
+		stored, err := db.GetBillingInformation(context.Background(), "user-0", model.PeriodTypeMonth, expectedFrom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stored) != 1 {
+			t.Fatalf("expected one stored tree for %v, got %v", expectedFrom, len(stored))
+		}
+		if !stored[0].To.Equal(expectedTo) {
+			t.Errorf("stored tree for %v: expected to %v, got %v", expectedFrom, expectedTo, stored[0].To)
+		}
+		if stored[0].Tree["user-0"].Month.Cpu != float64(expectedFrom.Month()) {
+			t.Errorf("stored tree for %v does not match calculator response: %#v", expectedFrom, stored[0].Tree)
+		}
+		expectedTo = expectedFrom
+	}
+
+	if len(publisher.BillingInformationCreated()) != nMonths {
+		t.Errorf("expected %v created events, got %v", nMonths, len(publisher.BillingInformationCreated()))
+	}
+	for _, event := range publisher.BillingInformationCreated() {
+		hash, err := model.TreeHash(event.Tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Hash != hash || event.Currency != "EUR" {
+			t.Errorf("expected hash %v and currency EUR in created event, got %v and %v", hash, event.Hash, event.Currency)
+		}
+	}
+	runs := publisher.BillingRunCompleted()
+	if len(runs) != 1 || runs[0].Trees != nMonths || runs[0].Users != 1 || runs[0].Months != nMonths {
+		t.Errorf("unexpected run completed events: %#v", runs)
+	}
+
+	if stored := testutil.ToFloat64(ctrl.metrics.TreesStored); stored != float64(nMonths) {
+		t.Errorf("expected %v stored trees in metrics, got %v", nMonths, stored)
+	}
+	if users := testutil.ToFloat64(ctrl.metrics.UsersProcessed); users != 1 {
+		t.Errorf("expected 1 processed user in metrics, got %v", users)
+	}
+	if periodTypes := testutil.CollectAndCount(ctrl.metrics.LastSuccessfulRuns); periodTypes != 1 {
+		t.Errorf("expected 1 last success gauge, got %v", periodTypes)
+	}
+	lastMonthEnd := ctrl.MonthStart(time.Now().In(ctrl.location).Year(), time.Now().In(ctrl.location).Month())
+	if end := testutil.ToFloat64(ctrl.metrics.LastBilledPeriodEnds.WithLabelValues(model.PeriodTypeMonth)); end != float64(lastMonthEnd.Unix()) {
+		t.Errorf("expected last billed period to end at %v, got %v", lastMonthEnd, time.Unix(int64(end), 0))
+	}
+}
+
+func TestStoreMonthlyBillingInformationPagination(t *testing.T) {
+	for _, nUsers := range []int{0, 49, 50, 100, 120} {
+		t.Run(strconv.Itoa(nUsers), func(t *testing.T) {
+			calc := newFakeCalculator(t)
+			db := database.NewMemory()
+			keycloak := newFakeKeycloak(nUsers)
+			ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, db, events.NewMemory())
+
+			err := ctrl.StoreMonthlyBillingInformation(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedPages := nUsers/50 + 1
+			if len(keycloak.pageSizes) != expectedPages {
+				t.Errorf("expected %v user pages, got %v", expectedPages, keycloak.pageSizes)
+			}
+			if len(calc.Requests()) != nUsers {
+				t.Errorf("expected %v calculator requests, got %v", nUsers, len(calc.Requests()))
+			}
+			for _, user := range keycloak.users {
+				dates, err := db.ListAvailableBillingInformation(context.Background(), *user.ID, model.PeriodTypeMonth)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(dates) != 1 {
+					t.Errorf("expected one billing month for %v, got %v", *user.ID, dates)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreBillingInformationForUser(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	keycloak := newFakeKeycloak(3)
+	ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, db, events.NewMemory())
+	periods, err := ctrl.Periods(model.PeriodTypeMonth, time.Now(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ctrl.StoreBillingInformationForUser(context.Background(), "user-1", periods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keycloak.pageSizes) != 0 {
+		t.Errorf("expected no keycloak user listing, got %v", keycloak.pageSizes)
+	}
+	for _, request := range calc.Requests() {
+		if request.ForUser != "user-1" {
+			t.Errorf("expected request for user-1, got %v", request.ForUser)
+		}
+	}
+	for _, user := range []string{"user-0", "user-1", "user-2"} {
+		dates, err := db.ListAvailableBillingInformation(context.Background(), user, model.PeriodTypeMonth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := 0
+		if user == "user-1" {
+			expected = 2
+		}
+		if len(dates) != expected {
+			t.Errorf("expected %v billing months for %v, got %v", expected, user, dates)
+		}
+	}
+}
+
+func TestStoreBillingInformationSkipsUnchangedTrees(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	publisher := events.NewMemory()
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(2), db, publisher)
+	periods := []model.Period{
+		{Type: model.PeriodTypeMonth, From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	// provisional snapshot of user-1 with the tree the fake calculator returns, must be replaced by a final one
+	err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+		PeriodType: model.PeriodTypeMonth,
+		From:       periods[0].From,
+		To:         periods[0].To,
+		CreatedAt:  periods[0].To.Add(-time.Hour),
+		UserId:     "user-1",
+		Tree:       calcmodel.CostTree{"user-1": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: 3}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ctrl.StoreBillingInformation(ctrl.WithRunId(context.Background(), "run-1"), periods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ctrl.StoreBillingInformation(ctrl.WithRunId(context.Background(), "run-2"), periods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for user, expected := range map[string]int{"user-0": 1, "user-1": 2} {
+		infos, err := db.GetBillingInformation(context.Background(), user, model.PeriodTypeMonth, periods[0].From)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != expected {
+			t.Fatalf("expected %v snapshots of %v, got %#v", expected, user, infos)
+		}
+		latest, _ := latestSnapshot(infos)
+		if latest.RunId != "run-1" || !latest.IsFinal() {
+			t.Errorf("expected final snapshot of run-1 for %v, got %#v", user, latest)
+		}
+	}
+
+	runs := publisher.BillingRunCompleted()
+	if len(runs) != 2 || runs[0].RunId != "run-1" || runs[0].Trees != 2 || runs[1].RunId != "run-2" || runs[1].Trees != 0 || runs[1].Unchanged != 2 {
+		t.Errorf("unexpected run completed events %#v", runs)
+	}
+	if len(publisher.BillingInformationCreated()) != 2 {
+		t.Errorf("expected 2 created events, got %v", len(publisher.BillingInformationCreated()))
+	}
+}
+
+func TestDryRunBillingInformation(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	publisher := events.NewMemory()
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(2), db, publisher)
+	periods := []model.Period{
+		{Type: model.PeriodTypeMonth, From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	older := time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 4, 2, 1, 0, 0, 0, time.UTC)
+	for createdAt, cpu := range map[time.Time]float64{older: 10, newer: 1} {
+		err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+			PeriodType: model.PeriodTypeMonth,
+			From:       periods[0].From,
+			To:         periods[0].To,
+			CreatedAt:  createdAt,
+			UserId:     "user-0",
+			Tree:       cpuTree(cpu),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := ctrl.DryRunBillingInformation(context.Background(), "", periods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Entries) != 2 {
+		t.Fatalf("expected 2 report entries, got %#v", report.Entries)
+	}
+	// the fake calculator bills the month number as cpu
+	first := report.Entries[0]
+	if first.UserId != "user-0" || first.StoredAt == nil || !first.StoredAt.Equal(newer) || first.OldTotal != 1 || first.NewTotal != 3 || first.Delta != 2 {
+		t.Errorf("unexpected entry for stored user: %#v", first)
+	}
+	second := report.Entries[1]
+	if second.UserId != "user-1" || second.StoredAt != nil || second.OldTotal != 0 || second.NewTotal != 3 || second.Delta != 3 {
+		t.Errorf("unexpected entry for new user: %#v", second)
+	}
+
+	for user, expected := range map[string]int{"user-0": 2, "user-1": 0} {
+		infos, err := db.GetBillingInformation(context.Background(), user, model.PeriodTypeMonth, periods[0].From)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(infos) != expected {
+			t.Errorf("expected %v stored snapshots of %v after dry run, got %v", expected, user, len(infos))
+		}
+	}
+	if len(publisher.BillingInformationCreated()) != 0 || len(publisher.BillingRunCompleted()) != 0 {
+		t.Error("expected no events to be published in a dry run")
+	}
+}
+
+func TestBackfillBillingInformation(t *testing.T) {
+	calc := newFakeCalculator(t)
+	db := database.NewMemory()
+	publisher := events.NewMemory()
+	ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(2), db, publisher)
+	periods, err := ctrl.PeriodsBetween(model.PeriodTypeMonth, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// final snapshot of november for user-0, provisional snapshot of december for user-1
+	for _, info := range []model.BillingInformation{
+		{PeriodType: model.PeriodTypeMonth, From: periods[0].From, To: periods[0].To, CreatedAt: periods[0].To.Add(time.Hour), UserId: "user-0", Tree: cpuTree(1)},
+		{PeriodType: model.PeriodTypeMonth, From: periods[1].From, To: periods[1].To, CreatedAt: periods[1].To.Add(-time.Hour), UserId: "user-1", Tree: cpuTree(1)},
+	} {
+		err = db.SetBillingInformation(context.Background(), info)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	progress := []model.BackfillProgress{}
+	err = ctrl.BackfillBillingInformation(context.Background(), "", periods, false, func(p model.BackfillProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][4]int{{1, 3, 1, 1}, {2, 3, 2, 0}, {3, 3, 2, 0}}
+	if len(progress) != len(expected) {
+		t.Fatalf("expected %v progress reports, got %#v", len(expected), progress)
+	}
+	for i, p := range progress {
+		actual := [4]int{p.Index, p.Total, p.Stored, p.Skipped}
+		if actual != expected[i] || !p.Period.From.Equal(periods[i].From) {
+			t.Errorf("progress %v: expected %v, got %v for %v", i, expected[i], actual, p.Period.From)
+		}
+	}
+	if len(calc.Requests()) != 5 {
+		t.Errorf("expected 5 calculator requests, got %v", len(calc.Requests()))
+	}
+	if len(publisher.BillingRunCompleted()) != 1 || publisher.BillingRunCompleted()[0].Trees != 5 || publisher.BillingRunCompleted()[0].Users != 2 {
+		t.Errorf("unexpected run completed events %#v", publisher.BillingRunCompleted())
+	}
+
+	progress = []model.BackfillProgress{}
+	err = ctrl.BackfillBillingInformation(context.Background(), "user-0", periods[:1], true, func(p model.BackfillProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 1 || progress[0].Stored != 1 || progress[0].Skipped != 0 {
+		t.Errorf("expected forced backfill to store the final month again, got %#v", progress)
+	}
+}
+
+func TestBillingRunPeriods(t *testing.T) {
+	ctrl := newTestController(t, nil, nil, database.NewMemory(), events.NewMemory())
+	periods, err := ctrl.BillingRunPeriods(model.BillingRunRequest{From: "2025-01-01", To: "2025-03-01"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 2 || periods[0].Type != model.PeriodTypeMonth || !periods[0].From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected periods %v", periods)
+	}
+	for _, request := range []model.BillingRunRequest{
+		{From: "2025-01-01"},
+		{From: "2025-01-01", To: "2025-03"},
+		{PeriodType: "day", From: "2025-01-01", To: "2025-03-01"},
+		{From: "2025-03-01", To: "2025-01-01"},
+		{PeriodType: "week", From: "2000-01-01", To: "2025-01-01"},
+		{From: "0001-01-01", To: "9999-01-01"},
+	} {
+		_, err = ctrl.BillingRunPeriods(request, time.Now())
+		if !errors.Is(err, model.ErrBadRequest) {
+			t.Errorf("expected bad request for %#v, got %v", request, err)
+		}
+	}
+}
+
+func TestBillingRunPeriodsLimit(t *testing.T) {
+	ctrl := newTestController(t, nil, nil, database.NewMemory(), events.NewMemory())
+	periods, err := ctrl.BillingRunPeriods(model.BillingRunRequest{From: "2000-01-01", To: "2021-09-01"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != MaxPeriodsBetween {
+		t.Errorf("expected %v periods, got %v", MaxPeriodsBetween, len(periods))
+	}
+	_, err = ctrl.BillingRunPeriods(model.BillingRunRequest{From: "2000-01-01", To: "2021-10-01"}, time.Now())
+	if !errors.Is(err, model.ErrBadRequest) {
+		t.Errorf("expected bad request for %v periods, got %v", MaxPeriodsBetween+1, err)
+	}
+}
+
+func TestStoreMonthlyBillingInformationErrors(t *testing.T) {
+	expected := errors.New("expected error")
+
+	t.Run("keycloak login", func(t *testing.T) {
+		calc := newFakeCalculator(t)
+		keycloak := newFakeKeycloak(1)
+		keycloak.loginErr = expected
+		ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, database.NewMemory(), events.NewMemory())
+		err := ctrl.StoreMonthlyBillingInformation(context.Background(), 1)
+		if !errors.Is(err, expected) {
+			t.Errorf("expected login error, got %v", err)
+		}
+	})
+
+	t.Run("keycloak users", func(t *testing.T) {
+		calc := newFakeCalculator(t)
+		keycloak := newFakeKeycloak(1)
+		keycloak.getUsersErr = expected
+		ctrl := newTestController(t, calculator.New(calc.server.URL), keycloak, database.NewMemory(), events.NewMemory())
+		err := ctrl.StoreMonthlyBillingInformation(context.Background(), 1)
+		if !errors.Is(err, expected) {
+			t.Errorf("expected user list error, got %v", err)
+		}
+	})
+
+	t.Run("calculator", func(t *testing.T) {
+		calc := newFakeCalculator(t)
+		calc.statusCode = http.StatusInternalServerError
+		db := database.NewMemory()
+		publisher := events.NewMemory()
+		ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(3), db, publisher)
+		err := ctrl.StoreMonthlyBillingInformation(context.Background(), 2)
+		if err == nil {
+			t.Fatal("expected calculator error")
+		}
+		if len(calc.Requests()) != 1 {
+			t.Errorf("expected the job to stop after the first failing request, got %v requests", len(calc.Requests()))
+		}
+		dates, err := db.ListAvailableBillingInformation(context.Background(), "user-0", model.PeriodTypeMonth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dates) != 0 {
+			t.Errorf("expected nothing to be stored, got %v", dates)
+		}
+		if len(publisher.BillingRunCompleted()) != 0 {
+			t.Error("expected no run completed event for a failed run")
+		}
+		if errs := testutil.ToFloat64(ctrl.metrics.CalculatorErrors); errs != 1 {
+			t.Errorf("expected 1 calculator error in metrics, got %v", errs)
+		}
+		if periodTypes := testutil.CollectAndCount(ctrl.metrics.LastSuccessfulRuns); periodTypes != 0 {
+			t.Errorf("expected no last success gauges for a failed run, got %v", periodTypes)
+		}
+		if periodTypes := testutil.CollectAndCount(ctrl.metrics.LastBilledPeriodEnds); periodTypes != 0 {
+			t.Errorf("expected no billed period gauges for a failed run, got %v", periodTypes)
+		}
+	})
+
+	t.Run("database", func(t *testing.T) {
+		calc := newFakeCalculator(t)
+		db := failingDatabase{Memory: database.NewMemory(), err: expected}
+		ctrl := newTestController(t, calculator.New(calc.server.URL), newFakeKeycloak(3), db, events.NewMemory())
+		err := ctrl.StoreMonthlyBillingInformation(context.Background(), 2)
+		if !errors.Is(err, expected) {
+			t.Errorf("expected database error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const aggregateUseridFieldName = "UserId"
+const aggregateFromFieldName = "From"
+const aggregateToFieldName = "To"
+
+var aggregateUseridKey string
+var aggregateFromKey string
+var aggregateToKey string
+
+func (db *Mongo) initAggregates() (err error) {
+	aggregateUseridKey, err = getBsonFieldName(model.BillingAggregate{}, aggregateUseridFieldName)
+	if err != nil {
+		return err
+	}
+	aggregateFromKey, err = getBsonFieldName(model.BillingAggregate{}, aggregateFromFieldName)
+	if err != nil {
+		return err
+	}
+	aggregateToKey, err = getBsonFieldName(model.BillingAggregate{}, aggregateToFieldName)
+	if err != nil {
+		return err
+	}
+	return db.ensureIndexes(db.aggregateCollection(), aggregateIndexes()...)
+}
+
+func aggregateIndexes() []indexDefinition {
+	return []indexDefinition{
+		{name: "userFromToindex", unique: true, keys: []indexKey{ascending(aggregateUseridKey), ascending(aggregateFromKey), ascending(aggregateToKey)}},
+	}
+}
+
+func (db *Mongo) aggregateCollection() *mongo.Collection {
+	return db.client.Database(db.config.MongoTable).Collection(db.config.MongoAggregateCollection)
+}
+
+func (db *Mongo) GetAggregate(ctx context.Context, userId string, from time.Time, to time.Time) (aggregate model.BillingAggregate, found bool, err error) {
+	err = db.aggregateCollection().FindOne(ctx, bson.M{aggregateUseridKey: userId, aggregateFromKey: from, aggregateToKey: to}).Decode(&aggregate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return aggregate, false, nil
+	}
+	if err != nil {
+		return aggregate, false, err
+	}
+	return aggregate, true, nil
+}
+
+func (db *Mongo) SetAggregate(ctx context.Context, aggregate model.BillingAggregate) error {
+	_, err := db.aggregateCollection().ReplaceOne(ctx, bson.M{aggregateUseridKey: aggregate.UserId, aggregateFromKey: aggregate.From, aggregateToKey: aggregate.To}, aggregate, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (db *Mongo) RemoveAggregates(ctx context.Context, userId string, at time.Time) error {
+	_, err := db.aggregateCollection().DeleteMany(ctx, bson.M{
+		aggregateUseridKey: bson.M{"$in": bson.A{userId, ""}},
+		aggregateFromKey:   bson.M{"$lte": at},
+		aggregateToKey:     bson.M{"$gt": at},
+	})
+	return err
+}
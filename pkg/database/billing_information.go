@@ -18,7 +18,7 @@ package database
 
 import (
 	"context"
-	"slices"
+	"errors"
 	"time"
 
 	"github.com/SENERGY-Platform/billing/pkg/model"
@@ -28,47 +28,100 @@ import (
 )
 
 const useridFieldName = "UserId"
+const periodTypeFieldName = "PeriodType"
 const fromFieldName = "From"
+const toFieldName = "To"
 const createdAtFieldName = "CreatedAt"
+const runIdFieldName = "RunId"
+const hashFieldName = "Hash"
+const currencyFieldName = "Currency"
+const treeFieldName = "Tree"
 
 var useridKey string
+var periodTypeKey string
 var fromKey string
+var toKey string
 var createdAtKey string
+var runIdKey string
+var hashKey string
+var currencyKey string
+var treeKey string
 
 func (db *Mongo) initBillingInformation() (err error) {
 	useridKey, err = getBsonFieldName(model.BillingInformation{}, useridFieldName)
 	if err != nil {
 		return err
 	}
+	periodTypeKey, err = getBsonFieldName(model.BillingInformation{}, periodTypeFieldName)
+	if err != nil {
+		return err
+	}
 	fromKey, err = getBsonFieldName(model.BillingInformation{}, fromFieldName)
 	if err != nil {
 		return err
 	}
+	toKey, err = getBsonFieldName(model.BillingInformation{}, toFieldName)
+	if err != nil {
+		return err
+	}
 	createdAtKey, err = getBsonFieldName(model.BillingInformation{}, createdAtFieldName)
 	if err != nil {
 		return err
 	}
-
-	collection := db.client.Database(db.config.MongoTable).Collection(db.config.MongoCollection)
-	err = db.ensureCompoundIndex(collection, "userFromindex", true, false, useridKey, fromKey)
+	runIdKey, err = getBsonFieldName(model.BillingInformation{}, runIdFieldName)
+	if err != nil {
+		return err
+	}
+	hashKey, err = getBsonFieldName(model.BillingInformation{}, hashFieldName)
 	if err != nil {
 		return err
 	}
-	err = db.ensureCompoundIndex(collection, "userFromCreatedAtindex", true, true, useridKey, fromKey, createdAtKey)
+	currencyKey, err = getBsonFieldName(model.BillingInformation{}, currencyFieldName)
+	if err != nil {
+		return err
+	}
+	treeKey, err = getBsonFieldName(model.BillingInformation{}, treeFieldName)
+	if err != nil {
+		return err
+	}
+	schemaVersionKey, err = getBsonFieldName(model.BillingInformation{}, schemaVersionFieldName)
 	if err != nil {
 		return err
 	}
-	return nil
 
+	collection := db.billingInformationCollection()
+	// replaced by userPeriodFromCreatedAtindex, which allows periods of different types to start at the same time
+	err = db.dropIndex(collection, "userFromCreatedAtindex")
+	if err != nil {
+		return err
+	}
+	return db.ensureIndexes(collection, billingInformationIndexes()...)
+}
+
+func billingInformationIndexes() []indexDefinition {
+	return []indexDefinition{
+		{name: "userFromindex", keys: []indexKey{ascending(useridKey), ascending(fromKey)}},
+		// newest snapshot first within a period, as read by GetLatestBillingInformation and storeTree
+		{name: "userPeriodFromCreatedAtindex", unique: true, keys: []indexKey{ascending(useridKey), ascending(periodTypeKey), ascending(fromKey), descending(createdAtKey)}},
+	}
 }
 
 func (db *Mongo) billingInformationCollection() *mongo.Collection {
 	return db.client.Database(db.config.MongoTable).Collection(db.config.MongoCollection)
 }
 
-func (db *Mongo) GetBillingInformation(ctx context.Context, userId string, from time.Time) (trees []model.BillingInformation, err error) {
+// periodTypeFilter matches documents without period type as monthly billing information.
+func periodTypeFilter(periodType model.PeriodType) interface{} {
+	periodType = periodTypeOrDefault(periodType)
+	if periodType == model.PeriodTypeMonth {
+		return bson.M{"$in": bson.A{periodType, nil}}
+	}
+	return periodType
+}
+
+func (db *Mongo) GetBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time) (trees []model.BillingInformation, err error) {
 	trees = []model.BillingInformation{}
-	cursor, err := db.billingInformationCollection().Find(ctx, bson.M{useridKey: userId, fromKey: from}, &options.FindOptions{Sort: bson.M{fromKey: -1}})
+	cursor, err := db.billingInformationCollection().Find(ctx, bson.M{useridKey: userId, periodTypeKey: periodTypeFilter(periodType), fromKey: from}, &options.FindOptions{Sort: bson.M{fromKey: -1}})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return trees, nil
@@ -79,43 +132,196 @@ func (db *Mongo) GetBillingInformation(ctx context.Context, userId string, from
 	if err != nil {
 		return trees, err
 	}
+	for i := range trees {
+		trees[i].PeriodType = periodTypeOrDefault(trees[i].PeriodType)
+	}
 	return
 }
 
-func (db *Mongo) ListAvailableBillingInformation(ctx context.Context, userId string) (dates []time.Time, err error) {
-	dates = []time.Time{}
-	opt := options.Find().SetProjection(bson.M{fromKey: 1, "_id": 0}).SetSort(bson.M{createdAtKey: -1})
+// periodSummaryDocument is the result of the grouping in ListAvailableBillingInformation.
+type periodSummaryDocument struct {
+	From            time.Time `bson:"_id"`
+	To              time.Time `bson:"to"`
+	Snapshots       int       `bson:"snapshots"`
+	LatestCreatedAt time.Time `bson:"latest_created_at"`
+}
 
-	cursor, err := db.billingInformationCollection().Find(ctx, bson.M{useridKey: userId}, opt)
+func (db *Mongo) ListAvailableBillingInformation(ctx context.Context, userId string, periodType model.PeriodType) (periods []model.BillingPeriodSummary, err error) {
+	periods = []model.BillingPeriodSummary{}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{useridKey: userId, periodTypeKey: periodTypeFilter(periodType)}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$" + fromKey},
+			{Key: "to", Value: bson.M{"$max": "$" + toKey}},
+			{Key: "snapshots", Value: bson.M{"$sum": 1}},
+			{Key: "latest_created_at", Value: bson.M{"$max": "$" + createdAtKey}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cursor, err := db.billingInformationCollection().Aggregate(ctx, pipeline)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return dates, nil
-		}
-		return dates, err
+		return periods, err
 	}
-	wrappedFroms := []WrappedFrom{}
-	err = cursor.All(ctx, &wrappedFroms)
+	documents := []periodSummaryDocument{}
+	err = cursor.All(ctx, &documents)
 	if err != nil {
-		return dates, err
+		return periods, err
 	}
-	for _, d := range wrappedFroms {
-		dates = append(dates, d.From)
+	for _, document := range documents {
+		periods = append(periods, model.BillingPeriodSummary{
+			From:            document.From.UTC(),
+			To:              document.To.UTC(),
+			Snapshots:       document.Snapshots,
+			LatestCreatedAt: document.LatestCreatedAt.UTC(),
+		})
 	}
-	// make distinct entries
-	// distinct in db does not work well with sort
-	return slices.Compact(dates), err
+	return periods, nil
 }
 
 func (db *Mongo) SetBillingInformation(ctx context.Context, billingInformation model.BillingInformation) error {
-	_, err := db.billingInformationCollection().ReplaceOne(ctx, bson.M{useridKey: billingInformation.UserId, createdAtKey: billingInformation.CreatedAt, fromKey: billingInformation.From}, billingInformation, options.Replace().SetUpsert(true))
+	billingInformation.PeriodType = periodTypeOrDefault(billingInformation.PeriodType)
+	hash, err := billingInformation.TreeHash()
+	if err != nil {
+		return err
+	}
+	billingInformation.Hash = hash
+	billingInformation.SchemaVersion = latestSchemaVersion
+	filter := bson.M{useridKey: billingInformation.UserId, periodTypeKey: periodTypeFilter(billingInformation.PeriodType), fromKey: billingInformation.From}
+	if billingInformation.RunId != "" {
+		// a retried run replaces the snapshot it stored before
+		filter[runIdKey] = billingInformation.RunId
+	} else {
+		filter[createdAtKey] = billingInformation.CreatedAt
+	}
+	_, err = db.billingInformationCollection().ReplaceOne(ctx, filter, billingInformation, options.Replace().SetUpsert(true))
 	return err
 }
 
-func (db *Mongo) RemoveInstance(ctx context.Context, userId string, from time.Time, createdAt time.Time) error {
-	_, err := db.billingInformationCollection().DeleteOne(ctx, bson.M{userId: userId, createdAtKey: createdAt, fromKey: from})
+func (db *Mongo) RemoveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	_, err := db.billingInformationCollection().DeleteOne(ctx, bson.M{useridKey: userId, periodTypeKey: periodTypeFilter(periodType), createdAtKey: createdAt, fromKey: from})
 	return err
 }
 
-type WrappedFrom struct {
-	From time.Time
+func (db *Mongo) ListSnapshots(ctx context.Context, periodType model.PeriodType, from time.Time) (snapshots []model.Snapshot, err error) {
+	return db.findSnapshots(ctx, bson.M{periodTypeKey: periodTypeFilter(periodType), fromKey: from}, bson.D{{Key: useridKey, Value: 1}, {Key: createdAtKey, Value: 1}})
+}
+
+func (db *Mongo) ListAllSnapshots(ctx context.Context) (snapshots []model.Snapshot, err error) {
+	return db.findSnapshots(ctx, bson.M{}, allSnapshotsSort)
+}
+
+func (db *Mongo) EachAllSnapshots(ctx context.Context, f func(snapshot model.Snapshot) error) error {
+	return db.eachSnapshot(ctx, bson.M{}, allSnapshotsSort, f)
+}
+
+var allSnapshotsSort = bson.D{{Key: useridKey, Value: 1}, {Key: periodTypeKey, Value: 1}, {Key: fromKey, Value: 1}, {Key: createdAtKey, Value: 1}}
+
+func (db *Mongo) EachSnapshot(ctx context.Context, periodType model.PeriodType, from time.Time, f func(snapshot model.Snapshot) error) error {
+	return db.eachSnapshot(ctx, bson.M{periodTypeKey: periodTypeFilter(periodType), fromKey: from}, bson.D{{Key: useridKey, Value: 1}, {Key: createdAtKey, Value: 1}}, f)
+}
+
+// findSnapshots loads the matching billing information without trees.
+func (db *Mongo) findSnapshots(ctx context.Context, filter bson.M, sort bson.D) (snapshots []model.Snapshot, err error) {
+	snapshots = []model.Snapshot{}
+	err = db.eachSnapshot(ctx, filter, sort, func(snapshot model.Snapshot) error {
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	return snapshots, err
+}
+
+// eachSnapshot calls f with the matching billing information without trees, decoded one by one from the cursor.
+func (db *Mongo) eachSnapshot(ctx context.Context, filter bson.M, sort bson.D, f func(snapshot model.Snapshot) error) error {
+	opt := options.Find().
+		SetProjection(bson.M{treeKey: 0, "_id": 0}).
+		SetSort(sort).
+		SetAllowDiskUse(true)
+	cursor, err := db.billingInformationCollection().Find(ctx, filter, opt)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		info := model.BillingInformation{}
+		err = cursor.Decode(&info)
+		if err != nil {
+			return err
+		}
+		info.PeriodType = periodTypeOrDefault(info.PeriodType)
+		snapshot, err := info.Snapshot()
+		if err != nil {
+			return err
+		}
+		err = f(snapshot)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (db *Mongo) ArchiveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	document, err := db.billingInformationCollection().FindOne(ctx, bson.M{useridKey: userId, periodTypeKey: periodTypeFilter(periodType), createdAtKey: createdAt, fromKey: from}).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// upsert by id, so an archive interrupted before the delete can be repeated
+	id := document.Lookup("_id")
+	_, err = db.archiveCollection().ReplaceOne(ctx, bson.M{"_id": id}, document, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	_, err = db.billingInformationCollection().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (db *Mongo) archiveCollection() *mongo.Collection {
+	return db.client.Database(db.config.MongoTable).Collection(db.config.MongoArchiveCollection)
+}
+
+func (db *Mongo) GetLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time) (trees []model.BillingInformation, err error) {
+	trees = []model.BillingInformation{}
+	err = db.EachLatestBillingInformation(ctx, userId, periodType, from, to, func(info model.BillingInformation) error {
+		trees = append(trees, info)
+		return nil
+	})
+	return trees, err
+}
+
+func (db *Mongo) EachLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time, f func(info model.BillingInformation) error) error {
+	match := bson.M{periodTypeKey: periodTypeFilter(periodType), fromKey: bson.M{"$gte": from, "$lt": to}}
+	if userId != "" {
+		match[useridKey] = userId
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: createdAtKey, Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "user", Value: "$" + useridKey}, {Key: "from", Value: "$" + fromKey}}},
+			{Key: "latest", Value: bson.M{"$first": "$$ROOT"}},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$latest"}}},
+		{{Key: "$sort", Value: bson.D{{Key: useridKey, Value: 1}, {Key: fromKey, Value: 1}}}},
+	}
+	cursor, err := db.billingInformationCollection().Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		info := model.BillingInformation{}
+		err = cursor.Decode(&info)
+		if err != nil {
+			return err
+		}
+		info.PeriodType = periodTypeOrDefault(info.PeriodType)
+		err = f(info)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
 }
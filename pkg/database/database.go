@@ -0,0 +1,104 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+type Database interface {
+	Ping(ctx context.Context) error
+
+	GetBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time) (trees []model.BillingInformation, err error)
+	// ListAvailableBillingInformation returns every period of the type with at least one snapshot of the user, sorted by From.
+	ListAvailableBillingInformation(ctx context.Context, userId string, periodType model.PeriodType) (periods []model.BillingPeriodSummary, err error)
+	SetBillingInformation(ctx context.Context, billingInformation model.BillingInformation) error
+	RemoveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error
+
+	// GetLatestBillingInformation returns the newest snapshot of every user and period with From in [from, to).
+	// An empty userId selects all users.
+	GetLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time) (trees []model.BillingInformation, err error)
+	// EachLatestBillingInformation calls f with every snapshot GetLatestBillingInformation would return, reading them one by one from the database.
+	// It stops at the first error returned by f.
+	EachLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time, f func(info model.BillingInformation) error) error
+	// ListSnapshots returns every snapshot of the period for all users without their trees, sorted by user and creation time.
+	ListSnapshots(ctx context.Context, periodType model.PeriodType, from time.Time) (snapshots []model.Snapshot, err error)
+	// EachSnapshot calls f with every snapshot ListSnapshots would return, reading them one by one from the database.
+	// It stops at the first error returned by f.
+	EachSnapshot(ctx context.Context, periodType model.PeriodType, from time.Time, f func(snapshot model.Snapshot) error) error
+	// ListAllSnapshots returns every stored snapshot without its tree, sorted by user, period type, period start and creation time.
+	ListAllSnapshots(ctx context.Context) (snapshots []model.Snapshot, err error)
+	// EachAllSnapshots calls f with every snapshot ListAllSnapshots would return, reading them one by one from the database.
+	// It stops at the first error returned by f.
+	EachAllSnapshots(ctx context.Context, f func(snapshot model.Snapshot) error) error
+	// ArchiveInstance moves a single snapshot out of the billing information into the archive.
+	ArchiveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error
+
+	GetAggregate(ctx context.Context, userId string, from time.Time, to time.Time) (aggregate model.BillingAggregate, found bool, err error)
+	SetAggregate(ctx context.Context, aggregate model.BillingAggregate) error
+	// RemoveAggregates removes the aggregates of the user and the aggregates over all users containing the given instant.
+	RemoveAggregates(ctx context.Context, userId string, at time.Time) error
+
+	// ListExchangeRates returns the exchange rate table, sorted by currencies and validity.
+	ListExchangeRates(ctx context.Context) (rates model.ExchangeRates, err error)
+	// SetExchangeRate stores the rate, replacing the rate of the same currencies valid from the same instant.
+	SetExchangeRate(ctx context.Context, rate model.ExchangeRate) error
+	// RemoveExchangeRate removes the rate of the currencies valid from the given instant, if it exists.
+	RemoveExchangeRate(ctx context.Context, from string, to string, validFrom time.Time) error
+
+	// Migrations lists the known data migrations and when they were applied.
+	Migrations(ctx context.Context) (migrations []model.Migration, err error)
+	// Migrate applies the pending data migrations in order and returns them.
+	// Callers have to hold the migration lease, so concurrent instances do not apply the same migrations.
+	Migrate(ctx context.Context) (applied []model.Migration, err error)
+
+	// TryLock acquires the lease on name for owner, or renews it if owner already holds it, until ttl passed.
+	// It returns false if another owner holds an unexpired lease.
+	TryLock(ctx context.Context, name string, owner string, ttl time.Duration) (acquired bool, err error)
+	// Unlock releases the lease on name if it is held by owner.
+	Unlock(ctx context.Context, name string, owner string) error
+}
+
+const TypeMongo = "mongo"
+const TypePostgres = "postgres"
+
+// periodTypeOrDefault treats billing information stored before the introduction of period types as monthly.
+func periodTypeOrDefault(periodType model.PeriodType) model.PeriodType {
+	if periodType == "" {
+		return model.PeriodTypeMonth
+	}
+	return periodType
+}
+
+// New creates the database selected by config.DatabaseType, defaulting to mongo.
+// The non-persistent Memory is not selectable, it would lose all billing information on restart.
+func New(conf configuration.Config, ctx context.Context, wg *sync.WaitGroup) (Database, error) {
+	switch conf.DatabaseType {
+	case TypeMongo, "":
+		return NewMongo(conf, ctx, wg)
+	case TypePostgres:
+		return NewPostgres(conf, ctx, wg)
+	default:
+		return nil, errors.New("unknown database_type '" + conf.DatabaseType + "'")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// testListAvailableBillingInformation checks the listing against any Database implementation.
+func testListAvailableBillingInformation(t *testing.T, db Database) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := func(userId string, from time.Time, createdAt time.Time) {
+		err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+			PeriodType: model.PeriodTypeMonth,
+			From:       from,
+			To:         from.AddDate(0, 1, 0),
+			CreatedAt:  createdAt,
+			UserId:     userId,
+			Tree:       calcmodel.CostTree{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// snapshots of different months interleave in creation order
+	store("user-0", february, february.AddDate(0, 0, 1))
+	store("user-0", january, february.AddDate(0, 0, 2))
+	store("user-0", february, february.AddDate(0, 0, 3))
+	store("user-0", january, february.AddDate(0, 0, 4))
+	store("user-0", march, march.AddDate(0, 0, 1))
+	store("user-1", january, february.AddDate(0, 0, 5))
+
+	periods, err := db.ListAvailableBillingInformation(context.Background(), "user-0", model.PeriodTypeMonth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []model.BillingPeriodSummary{
+		{From: january, To: february, Snapshots: 2, LatestCreatedAt: february.AddDate(0, 0, 4)},
+		{From: february, To: march, Snapshots: 2, LatestCreatedAt: february.AddDate(0, 0, 3)},
+		{From: march, To: march.AddDate(0, 1, 0), Snapshots: 1, LatestCreatedAt: march.AddDate(0, 0, 1)},
+	}
+	if len(periods) != len(expected) {
+		t.Fatalf("expected %v periods, got %#v", len(expected), periods)
+	}
+	for i, period := range periods {
+		if !period.From.Equal(expected[i].From) || !period.To.Equal(expected[i].To) || period.Snapshots != expected[i].Snapshots || !period.LatestCreatedAt.Equal(expected[i].LatestCreatedAt) {
+			t.Errorf("period %v: expected %#v, got %#v", i, expected[i], period)
+		}
+	}
+
+	periods, err = db.ListAvailableBillingInformation(context.Background(), "user-0", model.PeriodTypeQuarter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 0 {
+		t.Errorf("expected no quarters, got %#v", periods)
+	}
+}
+
+func TestMemoryListAvailableBillingInformation(t *testing.T) {
+	testListAvailableBillingInformation(t, NewMemory())
+}
+
+// testExchangeRates checks storing, replacing and removing exchange rates against any Database implementation.
+func testExchangeRates(t *testing.T, db Database) {
+	ctx := context.Background()
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, rate := range []model.ExchangeRate{
+		{From: "EUR", To: "USD", ValidFrom: january, Rate: 1.1},
+		{From: "EUR", To: "CHF", ValidFrom: february, Rate: 0.95},
+		{From: "EUR", To: "CHF", ValidFrom: january, Rate: 0.9},
+		{From: "EUR", To: "CHF", ValidFrom: february, Rate: 0.96},
+	} {
+		err := db.SetExchangeRate(ctx, rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := db.RemoveExchangeRate(ctx, "EUR", "USD", january)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := db.ListExchangeRates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := model.ExchangeRates{
+		{From: "EUR", To: "CHF", ValidFrom: january, Rate: 0.9},
+		{From: "EUR", To: "CHF", ValidFrom: february, Rate: 0.96},
+	}
+	if len(rates) != len(expected) {
+		t.Fatalf("expected %#v, got %#v", expected, rates)
+	}
+	for i, rate := range rates {
+		if rate.From != expected[i].From || rate.To != expected[i].To || !rate.ValidFrom.Equal(expected[i].ValidFrom) || rate.Rate != expected[i].Rate {
+			t.Errorf("rate %v: expected %#v, got %#v", i, expected[i], rate)
+		}
+	}
+}
+
+func TestMemoryExchangeRates(t *testing.T) {
+	testExchangeRates(t, NewMemory())
+}
@@ -0,0 +1,84 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exchangeRateFromFieldName = "From"
+const exchangeRateToFieldName = "To"
+const exchangeRateValidFromFieldName = "ValidFrom"
+
+var exchangeRateFromKey string
+var exchangeRateToKey string
+var exchangeRateValidFromKey string
+
+func (db *Mongo) initExchangeRates() (err error) {
+	exchangeRateFromKey, err = getBsonFieldName(model.ExchangeRate{}, exchangeRateFromFieldName)
+	if err != nil {
+		return err
+	}
+	exchangeRateToKey, err = getBsonFieldName(model.ExchangeRate{}, exchangeRateToFieldName)
+	if err != nil {
+		return err
+	}
+	exchangeRateValidFromKey, err = getBsonFieldName(model.ExchangeRate{}, exchangeRateValidFromFieldName)
+	if err != nil {
+		return err
+	}
+	return db.ensureIndexes(db.exchangeRateCollection(), exchangeRateIndexes()...)
+}
+
+func exchangeRateIndexes() []indexDefinition {
+	return []indexDefinition{
+		{name: "fromToValidFromindex", unique: true, keys: []indexKey{ascending(exchangeRateFromKey), ascending(exchangeRateToKey), ascending(exchangeRateValidFromKey)}},
+	}
+}
+
+func (db *Mongo) exchangeRateCollection() *mongo.Collection {
+	return db.client.Database(db.config.MongoTable).Collection(db.config.MongoExchangeRateCollection)
+}
+
+func (db *Mongo) ListExchangeRates(ctx context.Context) (rates model.ExchangeRates, err error) {
+	cursor, err := db.exchangeRateCollection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: exchangeRateFromKey, Value: 1}, {Key: exchangeRateToKey, Value: 1}, {Key: exchangeRateValidFromKey, Value: 1}}))
+	if err != nil {
+		return rates, err
+	}
+	rates = model.ExchangeRates{}
+	err = cursor.All(ctx, &rates)
+	for i := range rates {
+		rates[i].ValidFrom = rates[i].ValidFrom.UTC()
+	}
+	return rates, err
+}
+
+func (db *Mongo) SetExchangeRate(ctx context.Context, rate model.ExchangeRate) error {
+	_, err := db.exchangeRateCollection().ReplaceOne(ctx, bson.M{exchangeRateFromKey: rate.From, exchangeRateToKey: rate.To, exchangeRateValidFromKey: rate.ValidFrom}, rate, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (db *Mongo) RemoveExchangeRate(ctx context.Context, from string, to string, validFrom time.Time) error {
+	_, err := db.exchangeRateCollection().DeleteOne(ctx, bson.M{exchangeRateFromKey: from, exchangeRateToKey: to, exchangeRateValidFromKey: validFrom})
+	return err
+}
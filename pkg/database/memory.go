@@ -0,0 +1,360 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+// Memory is a non-persistent Database for tests. It can not be selected by database_type.
+type Memory struct {
+	mux                sync.Mutex
+	billingInformation []model.BillingInformation
+	archive            []model.BillingInformation
+	aggregates         []model.BillingAggregate
+	exchangeRates      model.ExchangeRates
+	locks              map[string]lockDocument
+}
+
+var _ Database = &Memory{}
+
+func NewMemory() *Memory {
+	return &Memory{locks: map[string]lockDocument{}}
+}
+
+func (db *Memory) Ping(context.Context) error {
+	return nil
+}
+
+func (db *Memory) GetBillingInformation(_ context.Context, userId string, periodType model.PeriodType, from time.Time) (trees []model.BillingInformation, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	trees = []model.BillingInformation{}
+	for _, info := range db.billingInformation {
+		if info.UserId == userId && info.PeriodType == periodTypeOrDefault(periodType) && info.From.Equal(from) {
+			trees = append(trees, info)
+		}
+	}
+	return trees, nil
+}
+
+func (db *Memory) ListAvailableBillingInformation(_ context.Context, userId string, periodType model.PeriodType) (periods []model.BillingPeriodSummary, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	periods = []model.BillingPeriodSummary{}
+	for _, info := range db.billingInformation {
+		if info.UserId != userId || info.PeriodType != periodTypeOrDefault(periodType) {
+			continue
+		}
+		index := slices.IndexFunc(periods, func(period model.BillingPeriodSummary) bool {
+			return period.From.Equal(info.From)
+		})
+		if index < 0 {
+			periods = append(periods, model.BillingPeriodSummary{From: info.From, To: info.To})
+			index = len(periods) - 1
+		}
+		periods[index].Snapshots++
+		if info.To.After(periods[index].To) {
+			periods[index].To = info.To
+		}
+		if info.CreatedAt.After(periods[index].LatestCreatedAt) {
+			periods[index].LatestCreatedAt = info.CreatedAt
+		}
+	}
+	slices.SortFunc(periods, func(a, b model.BillingPeriodSummary) int {
+		return a.From.Compare(b.From)
+	})
+	return periods, nil
+}
+
+func (db *Memory) SetBillingInformation(_ context.Context, billingInformation model.BillingInformation) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	billingInformation.PeriodType = periodTypeOrDefault(billingInformation.PeriodType)
+	hash, err := billingInformation.TreeHash()
+	if err != nil {
+		return err
+	}
+	billingInformation.Hash = hash
+	index := slices.IndexFunc(db.billingInformation, func(info model.BillingInformation) bool {
+		if info.UserId != billingInformation.UserId || info.PeriodType != billingInformation.PeriodType || !info.From.Equal(billingInformation.From) {
+			return false
+		}
+		if billingInformation.RunId != "" {
+			return info.RunId == billingInformation.RunId
+		}
+		return info.CreatedAt.Equal(billingInformation.CreatedAt)
+	})
+	if index >= 0 {
+		db.billingInformation[index] = billingInformation
+	} else {
+		db.billingInformation = append(db.billingInformation, billingInformation)
+	}
+	return nil
+}
+
+func (db *Memory) RemoveInstance(_ context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.billingInformation = slices.DeleteFunc(db.billingInformation, func(info model.BillingInformation) bool {
+		return info.UserId == userId && info.PeriodType == periodTypeOrDefault(periodType) && info.From.Equal(from) && info.CreatedAt.Equal(createdAt)
+	})
+	return nil
+}
+
+func (db *Memory) GetLatestBillingInformation(_ context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time) (trees []model.BillingInformation, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	trees = []model.BillingInformation{}
+	for _, info := range db.billingInformation {
+		if (userId != "" && info.UserId != userId) || info.PeriodType != periodTypeOrDefault(periodType) || info.From.Before(from) || !info.From.Before(to) {
+			continue
+		}
+		index := slices.IndexFunc(trees, func(other model.BillingInformation) bool {
+			return other.UserId == info.UserId && other.From.Equal(info.From)
+		})
+		if index < 0 {
+			trees = append(trees, info)
+		} else if info.CreatedAt.After(trees[index].CreatedAt) {
+			trees[index] = info
+		}
+	}
+	slices.SortFunc(trees, func(a, b model.BillingInformation) int {
+		if a.UserId != b.UserId {
+			return strings.Compare(a.UserId, b.UserId)
+		}
+		return a.From.Compare(b.From)
+	})
+	return trees, nil
+}
+
+func (db *Memory) ListSnapshots(_ context.Context, periodType model.PeriodType, from time.Time) (snapshots []model.Snapshot, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	snapshots = []model.Snapshot{}
+	for _, info := range db.billingInformation {
+		if info.PeriodType == periodTypeOrDefault(periodType) && info.From.Equal(from) {
+			snapshot, err := info.Snapshot()
+			if err != nil {
+				return snapshots, err
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortFunc(snapshots, func(a, b model.Snapshot) int {
+		if a.UserId != b.UserId {
+			return strings.Compare(a.UserId, b.UserId)
+		}
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+func (db *Memory) EachSnapshot(ctx context.Context, periodType model.PeriodType, from time.Time, f func(snapshot model.Snapshot) error) error {
+	snapshots, err := db.ListSnapshots(ctx, periodType, from)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		err = f(snapshot)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Memory) ListAllSnapshots(_ context.Context) (snapshots []model.Snapshot, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	snapshots = []model.Snapshot{}
+	for _, info := range db.billingInformation {
+		snapshot, err := info.Snapshot()
+		if err != nil {
+			return snapshots, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	slices.SortFunc(snapshots, compareSnapshots)
+	return snapshots, nil
+}
+
+func (db *Memory) EachAllSnapshots(ctx context.Context, f func(snapshot model.Snapshot) error) error {
+	snapshots, err := db.ListAllSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		err = f(snapshot)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareSnapshots orders snapshots by user, period and creation time.
+func compareSnapshots(a, b model.Snapshot) int {
+	if a.UserId != b.UserId {
+		return strings.Compare(a.UserId, b.UserId)
+	}
+	if a.PeriodType != b.PeriodType {
+		return strings.Compare(a.PeriodType, b.PeriodType)
+	}
+	if !a.From.Equal(b.From) {
+		return a.From.Compare(b.From)
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
+
+func (db *Memory) ArchiveInstance(_ context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.billingInformation = slices.DeleteFunc(db.billingInformation, func(info model.BillingInformation) bool {
+		matches := info.UserId == userId && info.PeriodType == periodTypeOrDefault(periodType) && info.From.Equal(from) && info.CreatedAt.Equal(createdAt)
+		if matches {
+			db.archive = append(db.archive, info)
+		}
+		return matches
+	})
+	return nil
+}
+
+// Archived returns the billing information moved to the archive by ArchiveInstance.
+func (db *Memory) Archived() []model.BillingInformation {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	return slices.Clone(db.archive)
+}
+
+// Migrations returns no migrations, the memory database never holds data of older versions.
+func (db *Memory) Migrations(context.Context) ([]model.Migration, error) {
+	return []model.Migration{}, nil
+}
+
+func (db *Memory) Migrate(context.Context) ([]model.Migration, error) {
+	return []model.Migration{}, nil
+}
+
+func (db *Memory) EachLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time, f func(info model.BillingInformation) error) error {
+	trees, err := db.GetLatestBillingInformation(ctx, userId, periodType, from, to)
+	if err != nil {
+		return err
+	}
+	for _, info := range trees {
+		err = f(info)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Memory) GetAggregate(_ context.Context, userId string, from time.Time, to time.Time) (aggregate model.BillingAggregate, found bool, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	for _, aggregate := range db.aggregates {
+		if aggregate.UserId == userId && aggregate.From.Equal(from) && aggregate.To.Equal(to) {
+			return aggregate, true, nil
+		}
+	}
+	return aggregate, false, nil
+}
+
+func (db *Memory) SetAggregate(_ context.Context, aggregate model.BillingAggregate) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	index := slices.IndexFunc(db.aggregates, func(other model.BillingAggregate) bool {
+		return other.UserId == aggregate.UserId && other.From.Equal(aggregate.From) && other.To.Equal(aggregate.To)
+	})
+	if index >= 0 {
+		db.aggregates[index] = aggregate
+	} else {
+		db.aggregates = append(db.aggregates, aggregate)
+	}
+	return nil
+}
+
+func (db *Memory) RemoveAggregates(_ context.Context, userId string, at time.Time) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.aggregates = slices.DeleteFunc(db.aggregates, func(aggregate model.BillingAggregate) bool {
+		return (aggregate.UserId == userId || aggregate.UserId == "") && !at.Before(aggregate.From) && at.Before(aggregate.To)
+	})
+	return nil
+}
+
+func (db *Memory) ListExchangeRates(context.Context) (rates model.ExchangeRates, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	rates = slices.Clone(db.exchangeRates)
+	if rates == nil {
+		rates = model.ExchangeRates{}
+	}
+	model.SortExchangeRates(rates)
+	return rates, nil
+}
+
+func (db *Memory) SetExchangeRate(_ context.Context, rate model.ExchangeRate) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	index := slices.IndexFunc(db.exchangeRates, func(other model.ExchangeRate) bool {
+		return other.From == rate.From && other.To == rate.To && other.ValidFrom.Equal(rate.ValidFrom)
+	})
+	if index >= 0 {
+		db.exchangeRates[index] = rate
+	} else {
+		db.exchangeRates = append(db.exchangeRates, rate)
+	}
+	return nil
+}
+
+func (db *Memory) RemoveExchangeRate(_ context.Context, from string, to string, validFrom time.Time) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.exchangeRates = slices.DeleteFunc(db.exchangeRates, func(rate model.ExchangeRate) bool {
+		return rate.From == from && rate.To == to && rate.ValidFrom.Equal(validFrom)
+	})
+	return nil
+}
+
+func (db *Memory) TryLock(_ context.Context, name string, owner string, ttl time.Duration) (acquired bool, err error) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	now := time.Now()
+	lock, exists := db.locks[name]
+	if exists && lock.Owner != owner && !lock.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	db.locks[name] = lockDocument{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (db *Memory) Unlock(_ context.Context, name string, owner string) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	if lock, exists := db.locks[name]; exists && lock.Owner == owner {
+		delete(db.locks, name)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestRetriedRunReplacesItsSnapshots(t *testing.T) {
+	db := NewMemory()
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, createdAt := range []time.Time{time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 2, 0, 0, 0, time.UTC)} {
+		err := db.SetBillingInformation(context.Background(), model.BillingInformation{
+			PeriodType: model.PeriodTypeMonth,
+			From:       from,
+			To:         from.AddDate(0, 1, 0),
+			CreatedAt:  createdAt,
+			RunId:      "run-1",
+			UserId:     "user-0",
+			Tree:       calcmodel.CostTree{"analytics": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: float64(i)}}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	infos, err := db.GetBillingInformation(context.Background(), "user-0", model.PeriodTypeMonth, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Tree["analytics"].Month.Cpu != 1 {
+		t.Errorf("expected the retry to replace the first snapshot, got %#v", infos)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migration changes billing information documents stored by older versions.
+// After up succeeded, all documents with an older schema version are set to the version of the migration.
+// Migrations are applied in order of their version. New migrations have to be appended with the next version.
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, db *Mongo) error
+}
+
+var migrations = []migration{
+	{version: 1, description: "set period type of billing information stored before period types were introduced", up: migratePeriodTypes},
+	{version: 2, description: "store tree hashes of billing information stored before hashes were introduced", up: migrateTreeHashes},
+	{version: 3, description: "set currency of billing information stored before currencies were recorded", up: migrateCurrencies},
+}
+
+// latestSchemaVersion is set on every billing information document written.
+var latestSchemaVersion = migrations[len(migrations)-1].version
+
+const schemaVersionFieldName = "SchemaVersion"
+
+var schemaVersionKey string
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+func (db *Mongo) migrationCollection() *mongo.Collection {
+	return db.client.Database(db.config.MongoTable).Collection(db.config.MongoMigrationCollection)
+}
+
+func (db *Mongo) Migrations(ctx context.Context) (result []model.Migration, err error) {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, m := range migrations {
+		entry := model.Migration{Version: m.version, Description: m.description}
+		if record, ok := records[m.version]; ok {
+			entry.AppliedAt = &record.AppliedAt
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (db *Mongo) appliedMigrations(ctx context.Context) (records map[int]migrationRecord, err error) {
+	records = map[int]migrationRecord{}
+	cursor, err := db.migrationCollection().Find(ctx, bson.M{})
+	if err != nil {
+		return records, err
+	}
+	list := []migrationRecord{}
+	err = cursor.All(ctx, &list)
+	if err != nil {
+		return records, err
+	}
+	for _, record := range list {
+		record.AppliedAt = record.AppliedAt.UTC()
+		records[record.Version] = record
+	}
+	return records, nil
+}
+
+// Migrate applies the pending migrations in order. The caller has to hold the migration lease, see controller.Migrate.
+func (db *Mongo) Migrate(ctx context.Context) (applied []model.Migration, err error) {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return applied, err
+	}
+	for _, m := range migrations {
+		if _, ok := records[m.version]; ok {
+			continue
+		}
+		log.Logger.Info("apply database migration", "version", m.version, "description", m.description)
+		err = m.up(ctx, db)
+		if err != nil {
+			return applied, errors.Join(errors.New("migration "+m.description+" failed"), err)
+		}
+		_, err = db.billingInformationCollection().UpdateMany(ctx, bson.M{schemaVersionKey: bson.M{"$not": bson.M{"$gte": m.version}}}, bson.M{"$set": bson.M{schemaVersionKey: m.version}})
+		if err != nil {
+			return applied, err
+		}
+		record := migrationRecord{Version: m.version, Description: m.description, AppliedAt: time.Now().UTC()}
+		_, err = db.migrationCollection().InsertOne(ctx, record)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, model.Migration{Version: record.Version, Description: record.Description, AppliedAt: &record.AppliedAt})
+	}
+	return applied, nil
+}
+
+// migratePeriodTypes sets the period type of documents stored before period types were introduced.
+// Queries still treat a missing period type as monthly, see periodTypeFilter.
+func migratePeriodTypes(ctx context.Context, db *Mongo) error {
+	_, err := db.billingInformationCollection().UpdateMany(ctx, bson.M{periodTypeKey: bson.M{"$in": bson.A{"", nil}}}, bson.M{"$set": bson.M{periodTypeKey: model.PeriodTypeMonth}})
+	return err
+}
+
+// migrateTreeHashes stores the tree hash of documents stored before hashes were introduced.
+func migrateTreeHashes(ctx context.Context, db *Mongo) error {
+	collection := db.billingInformationCollection()
+	cursor, err := collection.Find(ctx, bson.M{hashKey: bson.M{"$in": bson.A{"", nil}}}, options.Find().SetProjection(bson.M{treeKey: 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		info := model.BillingInformation{}
+		err = cursor.Decode(&info)
+		if err != nil {
+			return err
+		}
+		hash, err := model.TreeHash(info.Tree)
+		if err != nil {
+			return err
+		}
+		_, err = collection.UpdateByID(ctx, cursor.Current.Lookup("_id"), bson.M{"$set": bson.M{hashKey: hash}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// migrateCurrencies sets the configured currency on documents stored before the currency was recorded.
+// Reads still treat a missing currency as the configured one, see controller.CurrencyConverter.
+func migrateCurrencies(ctx context.Context, db *Mongo) error {
+	_, err := db.billingInformationCollection().UpdateMany(ctx, bson.M{currencyKey: bson.M{"$in": bson.A{"", nil}}}, bson.M{"$set": bson.M{currencyKey: db.config.Currency}})
+	return err
+}
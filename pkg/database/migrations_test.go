@@ -0,0 +1,41 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import "testing"
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %q: expected version %v, got %v", m.description, i+1, m.version)
+		}
+		if m.up == nil || m.description == "" {
+			t.Errorf("migration %v: missing description or up", m.version)
+		}
+	}
+	if latestSchemaVersion != len(migrations) {
+		t.Errorf("expected latest schema version %v, got %v", len(migrations), latestSchemaVersion)
+	}
+	for i, m := range postgresMigrations {
+		if m.version != i+1 {
+			t.Errorf("postgres migration %q: expected version %v, got %v", m.description, i+1, m.version)
+		}
+		if m.up == nil || m.description == "" {
+			t.Errorf("postgres migration %v: missing description or up", m.version)
+		}
+	}
+}
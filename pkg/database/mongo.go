@@ -31,6 +31,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 type Mongo struct {
@@ -38,8 +39,10 @@ type Mongo struct {
 	client *mongo.Client
 }
 
-func New(conf configuration.Config, ctx context.Context, wg *sync.WaitGroup) (*Mongo, error) {
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(conf.MongoUrl))
+var _ Database = &Mongo{}
+
+func NewMongo(conf configuration.Config, ctx context.Context, wg *sync.WaitGroup) (*Mongo, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(conf.MongoUrl).SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
 		return nil, err
 	}
@@ -55,9 +58,23 @@ func New(conf configuration.Config, ctx context.Context, wg *sync.WaitGroup) (*M
 		db.Disconnect()
 		return nil, err
 	}
+	err = db.initAggregates()
+	if err != nil {
+		db.Disconnect()
+		return nil, err
+	}
+	err = db.initExchangeRates()
+	if err != nil {
+		db.Disconnect()
+		return nil, err
+	}
 	return db, nil
 }
 
+func (db *Mongo) Ping(ctx context.Context) error {
+	return db.client.Ping(ctx, nil)
+}
+
 func (db *Mongo) CreateId() string {
 	return uuid.NewString()
 }
@@ -96,33 +113,120 @@ func (db *Mongo) Transaction(ctx context.Context) (resultCtx context.Context, cl
 	}, nil
 }
 
-func (db *Mongo) ensureIndex(collection *mongo.Collection, indexname string, indexKey string, asc bool, unique bool) error {
-	ctx, _ := getTimeoutContext()
-	var direction int32 = -1
-	if asc {
-		direction = 1
+// indexKey is a single key of an index with its own sort direction.
+type indexKey struct {
+	name string
+	asc  bool
+}
+
+func ascending(name string) indexKey {
+	return indexKey{name: name, asc: true}
+}
+
+func descending(name string) indexKey {
+	return indexKey{name: name, asc: false}
+}
+
+func (this indexKey) direction() int32 {
+	if this.asc {
+		return 1
+	}
+	return -1
+}
+
+// indexDefinition describes an index ensured on startup.
+type indexDefinition struct {
+	name   string
+	unique bool
+	keys   []indexKey
+}
+
+// ensureIndexes ensures every index of the definitions, see ensureCompoundIndex.
+func (db *Mongo) ensureIndexes(collection *mongo.Collection, definitions ...indexDefinition) error {
+	for _, definition := range definitions {
+		err := db.ensureCompoundIndex(collection, definition.name, definition.unique, definition.keys...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Mongo) ensureIndex(collection *mongo.Collection, indexname string, key indexKey, unique bool) error {
+	return db.ensureCompoundIndex(collection, indexname, unique, key)
+}
+
+// ensureCompoundIndex creates the index. An existing index with the same name but other keys, directions or uniqueness is replaced.
+func (db *Mongo) ensureCompoundIndex(collection *mongo.Collection, indexname string, unique bool, keys ...indexKey) error {
+	ctx, cancel := getTimeoutContext()
+	defer cancel()
+	definition := bson.D{}
+	for _, key := range keys {
+		definition = append(definition, bson.E{Key: key.name, Value: key.direction()})
 	}
-	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{indexKey, direction}},
+	specifications, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+	for _, specification := range specifications {
+		if specification.Name != indexname {
+			continue
+		}
+		if indexMatches(specification, keys, unique) {
+			return nil
+		}
+		log.Logger.Info("replace index with changed definition", "collection", collection.Name(), "index", indexname)
+		err = db.dropIndex(collection, indexname)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    definition,
 		Options: options.Index().SetName(indexname).SetUnique(unique),
 	})
 	return err
 }
 
-func (db *Mongo) ensureCompoundIndex(collection *mongo.Collection, indexname string, asc bool, unique bool, indexKeys ...string) error {
-	ctx, _ := getTimeoutContext()
-	var direction int32 = -1
-	if asc {
-		direction = 1
+// indexMatches reports if the existing index has exactly the given keys in order, with the same directions and uniqueness.
+func indexMatches(specification *mongo.IndexSpecification, keys []indexKey, unique bool) bool {
+	existingUnique := specification.Unique != nil && *specification.Unique
+	if existingUnique != unique {
+		return false
 	}
-	keys := []bson.E{}
-	for _, key := range indexKeys {
-		keys = append(keys, bson.E{Key: key, Value: direction})
+	existing := bson.D{}
+	err := bson.Unmarshal(specification.KeysDocument, &existing)
+	if err != nil || len(existing) != len(keys) {
+		return false
+	}
+	for i, element := range existing {
+		var direction float64
+		switch value := element.Value.(type) {
+		case int32:
+			direction = float64(value)
+		case int64:
+			direction = float64(value)
+		case float64:
+			direction = value
+		default:
+			// special indexes like text or hashed
+			return false
+		}
+		if element.Key != keys[i].name || (direction > 0) != keys[i].asc {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *Mongo) dropIndex(collection *mongo.Collection, indexname string) error {
+	ctx, cancel := getTimeoutContext()
+	defer cancel()
+	_, err := collection.Indexes().DropOne(ctx, indexname)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound" {
+		return nil
 	}
-	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D(keys),
-		Options: options.Index().SetName(indexname).SetUnique(unique),
-	})
 	return err
 }
 
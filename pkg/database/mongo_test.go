@@ -0,0 +1,186 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestMongo connects to the mongo at BILLING_TEST_MONGO_URL, using a new database which is dropped after the test.
+// Tests using it are skipped if the variable is not set.
+func newTestMongo(t *testing.T) *Mongo {
+	url := os.Getenv("BILLING_TEST_MONGO_URL")
+	if url == "" {
+		t.Skip("BILLING_TEST_MONGO_URL not set, skipping mongo integration test")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	config := &configuration.ConfigStruct{
+		MongoUrl:                    url,
+		MongoTable:                  "billing_test_" + uuid.NewString()[:8],
+		MongoCollection:             "trees",
+		MongoAggregateCollection:    "aggregates",
+		MongoLockCollection:         "locks",
+		MongoArchiveCollection:      "archive",
+		MongoMigrationCollection:    "migrations",
+		MongoExchangeRateCollection: "exchange_rates",
+		Currency:                    "EUR",
+	}
+	db, err := NewMongo(config, ctx, wg)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		err := db.client.Database(config.MongoTable).Drop(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		cancel()
+		wg.Wait()
+	})
+	return db
+}
+
+func TestMongoIndexes(t *testing.T) {
+	db := newTestMongo(t)
+	checkIndexes := func(collection *mongo.Collection, definitions []indexDefinition) {
+		specifications, err := collection.Indexes().ListSpecifications(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, definition := range definitions {
+			found := false
+			for _, specification := range specifications {
+				if specification.Name == definition.name {
+					found = true
+					if !indexMatches(specification, definition.keys, definition.unique) {
+						t.Errorf("index %v: expected %#v, got %v", definition.name, definition, specification.KeysDocument)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("index %v missing", definition.name)
+			}
+		}
+	}
+	checkIndexes(db.billingInformationCollection(), billingInformationIndexes())
+	checkIndexes(db.aggregateCollection(), aggregateIndexes())
+
+	// an index with an outdated definition is replaced
+	collection := db.billingInformationCollection()
+	_, err := collection.Indexes().DropOne(context.Background(), "userPeriodFromCreatedAtindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: useridKey, Value: 1}, {Key: periodTypeKey, Value: 1}, {Key: fromKey, Value: 1}, {Key: createdAtKey, Value: 1}},
+		Options: options.Index().SetName("userPeriodFromCreatedAtindex").SetUnique(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.initBillingInformation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIndexes(collection, billingInformationIndexes())
+}
+
+func TestMongoListAvailableBillingInformation(t *testing.T) {
+	testListAvailableBillingInformation(t, newTestMongo(t))
+}
+
+func TestMongoExchangeRates(t *testing.T) {
+	testExchangeRates(t, newTestMongo(t))
+}
+
+func TestMongoMigrations(t *testing.T) {
+	db := newTestMongo(t)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tree := calcmodel.CostTree{"analytics": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: 1}}}}
+	// a document as stored before period types, hashes, currencies and schema versions were introduced
+	_, err := db.billingInformationCollection().InsertOne(context.Background(), bson.M{
+		useridKey:    "legacy",
+		fromKey:      from,
+		toKey:        from.AddDate(0, 1, 0),
+		createdAtKey: from.AddDate(0, 1, 1),
+		treeKey:      tree,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := db.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("expected %v applied migrations, got %#v", len(migrations), applied)
+	}
+	list, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range list {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %v should have been applied", m.Version)
+		}
+	}
+
+	document := bson.M{}
+	err = db.billingInformationCollection().FindOne(context.Background(), bson.M{useridKey: "legacy"}).Decode(&document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := model.TreeHash(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document[periodTypeKey] != model.PeriodTypeMonth {
+		t.Errorf("expected period type %v, got %v", model.PeriodTypeMonth, document[periodTypeKey])
+	}
+	if document[hashKey] != hash {
+		t.Errorf("expected hash %v, got %v", hash, document[hashKey])
+	}
+	if document[currencyKey] != "EUR" {
+		t.Errorf("expected currency EUR, got %v", document[currencyKey])
+	}
+	if version, ok := document[schemaVersionKey].(int32); !ok || int(version) != latestSchemaVersion {
+		t.Errorf("expected schema version %v, got %v", latestSchemaVersion, document[schemaVersionKey])
+	}
+
+	applied, err = db.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no pending migrations, got %#v", applied)
+	}
+}
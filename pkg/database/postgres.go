@@ -0,0 +1,383 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Postgres struct {
+	config         configuration.Config
+	pool           *pgxpool.Pool
+	table          string
+	aggregateTable string
+	lockTable      string
+	archiveTable   string
+	rateTable      string
+	migrationTable string
+}
+
+var _ Database = &Postgres{}
+
+func NewPostgres(conf configuration.Config, ctx context.Context, wg *sync.WaitGroup) (*Postgres, error) {
+	pool, err := pgxpool.New(ctx, conf.PostgresUrl)
+	if err != nil {
+		return nil, err
+	}
+	wg.Add(1)
+	go func() {
+		<-ctx.Done()
+		pool.Close()
+		log.Logger.Debug("postgres pool closed")
+		wg.Done()
+	}()
+	db := &Postgres{
+		config:         conf,
+		pool:           pool,
+		table:          pgx.Identifier{conf.PostgresTable}.Sanitize(),
+		aggregateTable: pgx.Identifier{conf.PostgresTable + "_aggregates"}.Sanitize(),
+		lockTable:      pgx.Identifier{conf.PostgresTable + "_locks"}.Sanitize(),
+		archiveTable:   pgx.Identifier{conf.PostgresTable + "_archive"}.Sanitize(),
+		rateTable:      pgx.Identifier{conf.PostgresTable + "_exchange_rates"}.Sanitize(),
+		migrationTable: pgx.Identifier{conf.PostgresTable + "_migrations"}.Sanitize(),
+	}
+	timeoutCtx, cancel := getTimeoutContext()
+	defer cancel()
+	err = db.initBillingInformation(timeoutCtx)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *Postgres) Ping(ctx context.Context) error {
+	return db.pool.Ping(ctx)
+}
+
+func (db *Postgres) initBillingInformation(ctx context.Context) error {
+	statements := []string{
+		// periods of different types may start at the same time, so the period type is part of the unique index
+		`CREATE TABLE IF NOT EXISTS ` + db.table + ` (
+			user_id     TEXT        NOT NULL,
+			period_from TIMESTAMPTZ NOT NULL,
+			period_to   TIMESTAMPTZ NOT NULL,
+			created_at  TIMESTAMPTZ NOT NULL,
+			tree        JSONB       NOT NULL,
+			time_zone   TEXT        NOT NULL DEFAULT '',
+			period_type TEXT        NOT NULL DEFAULT '` + model.PeriodTypeMonth + `',
+			run_id      TEXT        NOT NULL DEFAULT '',
+			hash        TEXT        NOT NULL DEFAULT ''
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS ` + pgx.Identifier{db.config.PostgresTable + "_period_idx"}.Sanitize() + ` ON ` + db.table + ` (user_id, period_type, period_from, created_at)`,
+		// columns missing in tables created by older versions
+		`ALTER TABLE ` + db.table + ` ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE ` + db.table + ` ADD COLUMN IF NOT EXISTS period_type TEXT NOT NULL DEFAULT '` + model.PeriodTypeMonth + `'`,
+		`ALTER TABLE ` + db.table + ` ADD COLUMN IF NOT EXISTS run_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE ` + db.table + ` ADD COLUMN IF NOT EXISTS hash TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS ` + db.aggregateTable + ` (
+			user_id     TEXT          NOT NULL,
+			period_from TIMESTAMPTZ   NOT NULL,
+			period_to   TIMESTAMPTZ   NOT NULL,
+			months      TIMESTAMPTZ[] NOT NULL,
+			computed_at TIMESTAMPTZ   NOT NULL,
+			tree        JSONB         NOT NULL,
+			PRIMARY KEY (user_id, period_from, period_to)
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + db.lockTable + ` (
+			name       TEXT        PRIMARY KEY,
+			owner      TEXT        NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)`,
+		// columns added to the billing information table later have to be added to the archive as well
+		`CREATE TABLE IF NOT EXISTS ` + db.archiveTable + ` (LIKE ` + db.table + ` INCLUDING DEFAULTS)`,
+		// an empty currency is read as the configured currency
+		`ALTER TABLE ` + db.table + ` ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE ` + db.archiveTable + ` ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE ` + db.aggregateTable + ` ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT ''`,
+		`CREATE TABLE IF NOT EXISTS ` + db.migrationTable + ` (
+			version     INTEGER     PRIMARY KEY,
+			description TEXT        NOT NULL,
+			applied_at  TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + db.rateTable + ` (
+			currency_from TEXT             NOT NULL,
+			currency_to   TEXT             NOT NULL,
+			valid_from    TIMESTAMPTZ      NOT NULL,
+			rate          DOUBLE PRECISION NOT NULL,
+			PRIMARY KEY (currency_from, currency_to, valid_from)
+		)`,
+	}
+	for _, statement := range statements {
+		_, err := db.pool.Exec(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const postgresBillingInformationColumns = `user_id, period_type, period_from, period_to, created_at, run_id, time_zone, hash, currency, tree`
+
+func scanBillingInformation(rows pgx.Row) (info model.BillingInformation, err error) {
+	err = rows.Scan(&info.UserId, &info.PeriodType, &info.From, &info.To, &info.CreatedAt, &info.RunId, &info.TimeZone, &info.Hash, &info.Currency, &info.Tree)
+	info.From = info.From.UTC()
+	info.To = info.To.UTC()
+	info.CreatedAt = info.CreatedAt.UTC()
+	return info, err
+}
+
+func (db *Postgres) GetBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time) (trees []model.BillingInformation, err error) {
+	trees = []model.BillingInformation{}
+	rows, err := db.pool.Query(ctx, `SELECT `+postgresBillingInformationColumns+` FROM `+db.table+` WHERE user_id = $1 AND period_type = $2 AND period_from = $3 ORDER BY created_at`, userId, periodTypeOrDefault(periodType), from)
+	if err != nil {
+		return trees, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		info, err := scanBillingInformation(rows)
+		if err != nil {
+			return trees, err
+		}
+		trees = append(trees, info)
+	}
+	return trees, rows.Err()
+}
+
+func (db *Postgres) ListAvailableBillingInformation(ctx context.Context, userId string, periodType model.PeriodType) (periods []model.BillingPeriodSummary, err error) {
+	periods = []model.BillingPeriodSummary{}
+	rows, err := db.pool.Query(ctx, `SELECT period_from, MAX(period_to), COUNT(*), MAX(created_at) FROM `+db.table+` WHERE user_id = $1 AND period_type = $2 GROUP BY period_from ORDER BY period_from`, userId, periodTypeOrDefault(periodType))
+	if err != nil {
+		return periods, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		period := model.BillingPeriodSummary{}
+		err = rows.Scan(&period.From, &period.To, &period.Snapshots, &period.LatestCreatedAt)
+		if err != nil {
+			return periods, err
+		}
+		period.From = period.From.UTC()
+		period.To = period.To.UTC()
+		period.LatestCreatedAt = period.LatestCreatedAt.UTC()
+		periods = append(periods, period)
+	}
+	return periods, rows.Err()
+}
+
+func (db *Postgres) SetBillingInformation(ctx context.Context, billingInformation model.BillingInformation) error {
+	periodType := periodTypeOrDefault(billingInformation.PeriodType)
+	hash, err := billingInformation.TreeHash()
+	if err != nil {
+		return err
+	}
+	if billingInformation.RunId != "" {
+		// a retried run replaces the snapshot it stored before
+		tag, err := db.pool.Exec(ctx, `UPDATE `+db.table+` SET period_to = $4, created_at = $5, time_zone = $6, tree = $7, hash = $9, currency = $10
+			WHERE user_id = $1 AND period_type = $2 AND period_from = $3 AND run_id = $8`,
+			billingInformation.UserId, periodType, billingInformation.From, billingInformation.To, billingInformation.CreatedAt, billingInformation.TimeZone, billingInformation.Tree, billingInformation.RunId, hash, billingInformation.Currency)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() > 0 {
+			return nil
+		}
+	}
+	_, err = db.pool.Exec(ctx, `INSERT INTO `+db.table+` (`+postgresBillingInformationColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, period_type, period_from, created_at) DO UPDATE SET period_to = EXCLUDED.period_to, run_id = EXCLUDED.run_id, time_zone = EXCLUDED.time_zone, hash = EXCLUDED.hash, currency = EXCLUDED.currency, tree = EXCLUDED.tree`,
+		billingInformation.UserId, periodType, billingInformation.From, billingInformation.To, billingInformation.CreatedAt, billingInformation.RunId, billingInformation.TimeZone, hash, billingInformation.Currency, billingInformation.Tree)
+	return err
+}
+
+func (db *Postgres) RemoveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	_, err := db.pool.Exec(ctx, `DELETE FROM `+db.table+` WHERE user_id = $1 AND period_type = $2 AND period_from = $3 AND created_at = $4`, userId, periodTypeOrDefault(periodType), from, createdAt)
+	return err
+}
+
+func (db *Postgres) GetLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time) (trees []model.BillingInformation, err error) {
+	trees = []model.BillingInformation{}
+	err = db.EachLatestBillingInformation(ctx, userId, periodType, from, to, func(info model.BillingInformation) error {
+		trees = append(trees, info)
+		return nil
+	})
+	return trees, err
+}
+
+func (db *Postgres) EachLatestBillingInformation(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, to time.Time, f func(info model.BillingInformation) error) error {
+	rows, err := db.pool.Query(ctx, `SELECT DISTINCT ON (user_id, period_from) `+postgresBillingInformationColumns+` FROM `+db.table+`
+		WHERE ($1 = '' OR user_id = $1) AND period_type = $2 AND period_from >= $3 AND period_from < $4
+		ORDER BY user_id, period_from, created_at DESC`, userId, periodTypeOrDefault(periodType), from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		info, err := scanBillingInformation(rows)
+		if err != nil {
+			return err
+		}
+		err = f(info)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (db *Postgres) ListSnapshots(ctx context.Context, periodType model.PeriodType, from time.Time) (snapshots []model.Snapshot, err error) {
+	return db.querySnapshots(ctx, `WHERE period_type = $1 AND period_from = $2 ORDER BY user_id, created_at`, periodTypeOrDefault(periodType), from)
+}
+
+func (db *Postgres) ListAllSnapshots(ctx context.Context) (snapshots []model.Snapshot, err error) {
+	return db.querySnapshots(ctx, `ORDER BY user_id, period_type, period_from, created_at`)
+}
+
+func (db *Postgres) EachAllSnapshots(ctx context.Context, f func(snapshot model.Snapshot) error) error {
+	return db.eachSnapshot(ctx, f, `ORDER BY user_id, period_type, period_from, created_at`)
+}
+
+func (db *Postgres) EachSnapshot(ctx context.Context, periodType model.PeriodType, from time.Time, f func(snapshot model.Snapshot) error) error {
+	return db.eachSnapshot(ctx, f, `WHERE period_type = $1 AND period_from = $2 ORDER BY user_id, created_at`, periodTypeOrDefault(periodType), from)
+}
+
+// querySnapshots selects the billing information without trees, condition holds the WHERE and ORDER BY clauses.
+func (db *Postgres) querySnapshots(ctx context.Context, condition string, args ...any) (snapshots []model.Snapshot, err error) {
+	snapshots = []model.Snapshot{}
+	err = db.eachSnapshot(ctx, func(snapshot model.Snapshot) error {
+		snapshots = append(snapshots, snapshot)
+		return nil
+	}, condition, args...)
+	return snapshots, err
+}
+
+// eachSnapshot calls f with the billing information selected like querySnapshots, scanned row by row.
+func (db *Postgres) eachSnapshot(ctx context.Context, f func(snapshot model.Snapshot) error, condition string, args ...any) error {
+	rows, err := db.pool.Query(ctx, `SELECT user_id, period_type, period_from, period_to, created_at, run_id, hash FROM `+db.table+` `+condition, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		snapshot := model.Snapshot{}
+		err = rows.Scan(&snapshot.UserId, &snapshot.PeriodType, &snapshot.From, &snapshot.To, &snapshot.CreatedAt, &snapshot.RunId, &snapshot.Hash)
+		if err != nil {
+			return err
+		}
+		snapshot.From = snapshot.From.UTC()
+		snapshot.To = snapshot.To.UTC()
+		snapshot.CreatedAt = snapshot.CreatedAt.UTC()
+		err = f(snapshot)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (db *Postgres) ArchiveInstance(ctx context.Context, userId string, periodType model.PeriodType, from time.Time, createdAt time.Time) error {
+	_, err := db.pool.Exec(ctx, `WITH moved AS (
+			DELETE FROM `+db.table+` WHERE user_id = $1 AND period_type = $2 AND period_from = $3 AND created_at = $4 RETURNING `+postgresBillingInformationColumns+`
+		) INSERT INTO `+db.archiveTable+` (`+postgresBillingInformationColumns+`) SELECT `+postgresBillingInformationColumns+` FROM moved`,
+		userId, periodTypeOrDefault(periodType), from, createdAt)
+	return err
+}
+
+func (db *Postgres) GetAggregate(ctx context.Context, userId string, from time.Time, to time.Time) (aggregate model.BillingAggregate, found bool, err error) {
+	err = db.pool.QueryRow(ctx, `SELECT user_id, period_from, period_to, months, computed_at, currency, tree FROM `+db.aggregateTable+` WHERE user_id = $1 AND period_from = $2 AND period_to = $3`, userId, from, to).
+		Scan(&aggregate.UserId, &aggregate.From, &aggregate.To, &aggregate.Months, &aggregate.ComputedAt, &aggregate.Currency, &aggregate.Tree)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return aggregate, false, nil
+	}
+	if err != nil {
+		return aggregate, false, err
+	}
+	aggregate.From = aggregate.From.UTC()
+	aggregate.To = aggregate.To.UTC()
+	aggregate.ComputedAt = aggregate.ComputedAt.UTC()
+	for i, month := range aggregate.Months {
+		aggregate.Months[i] = month.UTC()
+	}
+	return aggregate, true, nil
+}
+
+func (db *Postgres) SetAggregate(ctx context.Context, aggregate model.BillingAggregate) error {
+	_, err := db.pool.Exec(ctx, `INSERT INTO `+db.aggregateTable+` (user_id, period_from, period_to, months, computed_at, currency, tree) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, period_from, period_to) DO UPDATE SET months = EXCLUDED.months, computed_at = EXCLUDED.computed_at, currency = EXCLUDED.currency, tree = EXCLUDED.tree`,
+		aggregate.UserId, aggregate.From, aggregate.To, aggregate.Months, aggregate.ComputedAt, aggregate.Currency, aggregate.Tree)
+	return err
+}
+
+func (db *Postgres) RemoveAggregates(ctx context.Context, userId string, at time.Time) error {
+	_, err := db.pool.Exec(ctx, `DELETE FROM `+db.aggregateTable+` WHERE user_id IN ($1, '') AND period_from <= $2 AND period_to > $2`, userId, at)
+	return err
+}
+
+func (db *Postgres) ListExchangeRates(ctx context.Context) (rates model.ExchangeRates, err error) {
+	rates = model.ExchangeRates{}
+	rows, err := db.pool.Query(ctx, `SELECT currency_from, currency_to, valid_from, rate FROM `+db.rateTable+` ORDER BY currency_from, currency_to, valid_from`)
+	if err != nil {
+		return rates, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rate := model.ExchangeRate{}
+		err = rows.Scan(&rate.From, &rate.To, &rate.ValidFrom, &rate.Rate)
+		if err != nil {
+			return rates, err
+		}
+		rate.ValidFrom = rate.ValidFrom.UTC()
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (db *Postgres) SetExchangeRate(ctx context.Context, rate model.ExchangeRate) error {
+	_, err := db.pool.Exec(ctx, `INSERT INTO `+db.rateTable+` (currency_from, currency_to, valid_from, rate) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (currency_from, currency_to, valid_from) DO UPDATE SET rate = EXCLUDED.rate`,
+		rate.From, rate.To, rate.ValidFrom, rate.Rate)
+	return err
+}
+
+func (db *Postgres) RemoveExchangeRate(ctx context.Context, from string, to string, validFrom time.Time) error {
+	_, err := db.pool.Exec(ctx, `DELETE FROM `+db.rateTable+` WHERE currency_from = $1 AND currency_to = $2 AND valid_from = $3`, from, to, validFrom)
+	return err
+}
+
+func (db *Postgres) TryLock(ctx context.Context, name string, owner string, ttl time.Duration) (acquired bool, err error) {
+	now := time.Now().UTC()
+	tag, err := db.pool.Exec(ctx, `INSERT INTO `+db.lockTable+` (name, owner, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE `+db.lockTable+`.owner = EXCLUDED.owner OR `+db.lockTable+`.expires_at < $4`, name, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (db *Postgres) Unlock(ctx context.Context, name string, owner string) error {
+	_, err := db.pool.Exec(ctx, `DELETE FROM `+db.lockTable+` WHERE name = $1 AND owner = $2`, name, owner)
+	return err
+}
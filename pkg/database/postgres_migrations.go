@@ -0,0 +1,137 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// postgresMigration changes rows stored by older versions. Table definitions are updated in initBillingInformation,
+// migrations cover the changes which can not be done in sql or take too long to run on every startup.
+// Migrations are applied in order of their version. New migrations have to be appended with the next version.
+type postgresMigration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, db *Postgres) error
+}
+
+var postgresMigrations = []postgresMigration{
+	{version: 1, description: "store tree hashes of billing information stored before hashes were introduced", up: fillMissingHashes},
+	{version: 2, description: "drop the primary key of tables created before period types were introduced", up: dropPeriodPrimaryKey},
+}
+
+func (db *Postgres) Migrations(ctx context.Context) (result []model.Migration, err error) {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, m := range postgresMigrations {
+		entry := model.Migration{Version: m.version, Description: m.description}
+		if appliedAt, ok := records[m.version]; ok {
+			entry.AppliedAt = &appliedAt
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (db *Postgres) appliedMigrations(ctx context.Context) (records map[int]time.Time, err error) {
+	records = map[int]time.Time{}
+	rows, err := db.pool.Query(ctx, `SELECT version, applied_at FROM `+db.migrationTable)
+	if err != nil {
+		return records, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return records, err
+		}
+		records[version] = appliedAt.UTC()
+	}
+	return records, rows.Err()
+}
+
+// Migrate applies the pending migrations in order. The caller has to hold the migration lease, see controller.Migrate.
+func (db *Postgres) Migrate(ctx context.Context) (applied []model.Migration, err error) {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return applied, err
+	}
+	for _, m := range postgresMigrations {
+		if _, ok := records[m.version]; ok {
+			continue
+		}
+		log.Logger.Info("apply database migration", "version", m.version, "description", m.description)
+		err = m.up(ctx, db)
+		if err != nil {
+			return applied, errors.Join(errors.New("migration "+m.description+" failed"), err)
+		}
+		appliedAt := time.Now().UTC()
+		_, err = db.pool.Exec(ctx, `INSERT INTO `+db.migrationTable+` (version, description, applied_at) VALUES ($1, $2, $3)`, m.version, m.description, appliedAt)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, model.Migration{Version: m.version, Description: m.description, AppliedAt: &appliedAt})
+	}
+	return applied, nil
+}
+
+// fillMissingHashes stores the tree hash of rows stored before hashes were introduced.
+// The hash is computed from the go encoding of the tree, so it can not be done in sql.
+func fillMissingHashes(ctx context.Context, db *Postgres) error {
+	rows, err := db.pool.Query(ctx, `SELECT `+postgresBillingInformationColumns+` FROM `+db.table+` WHERE hash = ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	batch := &pgx.Batch{}
+	for rows.Next() {
+		info, err := scanBillingInformation(rows)
+		if err != nil {
+			return err
+		}
+		hash, err := model.TreeHash(info.Tree)
+		if err != nil {
+			return err
+		}
+		batch.Queue(`UPDATE `+db.table+` SET hash = $5 WHERE user_id = $1 AND period_type = $2 AND period_from = $3 AND created_at = $4`,
+			info.UserId, info.PeriodType, info.From, info.CreatedAt, hash)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return db.pool.SendBatch(ctx, batch).Close()
+}
+
+// dropPeriodPrimaryKey drops the primary key on (user_id, period_from, created_at) of tables created by older versions.
+// It rejects periods of different types starting at the same time, the unique index including the period type replaces it.
+func dropPeriodPrimaryKey(ctx context.Context, db *Postgres) error {
+	_, err := db.pool.Exec(ctx, `ALTER TABLE `+db.table+` DROP CONSTRAINT IF EXISTS `+pgx.Identifier{db.config.PostgresTable + "_pkey"}.Sanitize())
+	return err
+}
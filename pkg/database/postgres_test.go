@@ -0,0 +1,136 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// newTestPostgres connects to the postgres at BILLING_TEST_POSTGRES_URL, using new tables which are dropped after the test.
+// Tests using it are skipped if the variable is not set.
+func newTestPostgres(t *testing.T) *Postgres {
+	url := os.Getenv("BILLING_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("BILLING_TEST_POSTGRES_URL not set, skipping postgres integration test")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	config := &configuration.ConfigStruct{
+		PostgresUrl:   url,
+		PostgresTable: "billing_test_" + uuid.NewString()[:8],
+		Currency:      "EUR",
+	}
+	db, err := NewPostgres(config, ctx, wg)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, table := range []string{db.table, db.aggregateTable, db.lockTable, db.archiveTable, db.rateTable, db.migrationTable} {
+			_, err := db.pool.Exec(context.Background(), `DROP TABLE IF EXISTS `+table)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		cancel()
+		wg.Wait()
+	})
+	return db
+}
+
+func TestPostgresListAvailableBillingInformation(t *testing.T) {
+	testListAvailableBillingInformation(t, newTestPostgres(t))
+}
+
+func TestPostgresExchangeRates(t *testing.T) {
+	testExchangeRates(t, newTestPostgres(t))
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	db := newTestPostgres(t)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tree := calcmodel.CostTree{"analytics": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: 1}}}}
+	// a row as stored before hashes were introduced, the columns added later get their defaults
+	_, err := db.pool.Exec(context.Background(), `INSERT INTO `+db.table+` (user_id, period_from, period_to, created_at, tree) VALUES ($1, $2, $3, $4, $5)`,
+		"legacy", from, from.AddDate(0, 1, 0), from.AddDate(0, 1, 1), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the primary key of tables created before period types were introduced
+	_, err = db.pool.Exec(context.Background(), `ALTER TABLE `+db.table+` ADD CONSTRAINT `+pgx.Identifier{db.config.PostgresTable + "_pkey"}.Sanitize()+` PRIMARY KEY (user_id, period_from, created_at)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := db.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(postgresMigrations) {
+		t.Errorf("expected %v applied migrations, got %#v", len(postgresMigrations), applied)
+	}
+	list, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range list {
+		if m.AppliedAt == nil {
+			t.Errorf("migration %v should have been applied", m.Version)
+		}
+	}
+
+	infos, err := db.GetBillingInformation(context.Background(), "legacy", model.PeriodTypeMonth, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := model.TreeHash(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].PeriodType != model.PeriodTypeMonth || infos[0].Hash != hash {
+		t.Errorf("expected monthly snapshot with hash %v, got %#v", hash, infos)
+	}
+	err = db.SetBillingInformation(context.Background(), model.BillingInformation{
+		UserId:     "legacy",
+		PeriodType: model.PeriodTypeQuarter,
+		From:       from,
+		To:         from.AddDate(0, 3, 0),
+		CreatedAt:  from.AddDate(0, 1, 1),
+		Tree:       tree,
+	})
+	if err != nil {
+		t.Errorf("expected a quarter starting with the month after dropping the primary key, got %v", err)
+	}
+
+	applied, err = db.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no pending migrations, got %#v", applied)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"github.com/segmentio/kafka-go"
+)
+
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(ctx context.Context, config configuration.Config, wg *sync.WaitGroup) (*Kafka, error) {
+	brokers := []string{}
+	for _, broker := range strings.Split(config.KafkaUrl, ",") {
+		brokers = append(brokers, strings.TrimSpace(broker))
+	}
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  config.KafkaTopic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		BatchTimeout:           10 * time.Millisecond,
+		AllowAutoTopicCreation: true,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		err := writer.Close()
+		if err != nil {
+			log.Logger.Error("unable to close kafka writer", attributes.ErrorKey, err)
+		}
+	}()
+	return &Kafka{writer: writer}, nil
+}
+
+func (this *Kafka) PublishBillingInformationCreated(ctx context.Context, info model.BillingInformation) error {
+	return this.publish(ctx, info.UserId, billingInformationCreatedEvent(info))
+}
+
+func (this *Kafka) PublishBillingRunCompleted(ctx context.Context, event model.BillingRunCompletedEvent) error {
+	return this.publish(ctx, "", billingRunCompletedEvent(event))
+}
+
+func (this *Kafka) publish(ctx context.Context, key string, event interface{}) error {
+	msg, err := message(key, event)
+	if err != nil {
+		return err
+	}
+	return this.writer.WriteMessages(ctx, msg)
+}
+
+// message encodes the event as json. Messages without key are spread over the partitions.
+func message(key string, event interface{}) (kafka.Message, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	msg := kafka.Message{Value: value}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
+	return msg, nil
+}
@@ -0,0 +1,99 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	calcmodel "github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestBillingInformationCreatedMessage(t *testing.T) {
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	info := model.BillingInformation{
+		UserId:     "user-1",
+		PeriodType: model.PeriodTypeMonth,
+		From:       from,
+		To:         from.AddDate(0, 1, 0),
+		CreatedAt:  from.AddDate(0, 1, 1),
+		RunId:      "run-1",
+		Currency:   "EUR",
+		Hash:       "hash-1",
+		Tree:       calcmodel.CostTree{"analytics": {CostWithEstimation: calcmodel.CostWithEstimation{Month: calcmodel.CostEntry{Cpu: 1}}}},
+	}
+	msg, err := message(info.UserId, billingInformationCreatedEvent(info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the user id as key keeps the events of a user in order
+	if string(msg.Key) != info.UserId {
+		t.Errorf("expected key %v, got %q", info.UserId, msg.Key)
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(msg.Value, &fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]interface{}{
+		"type":           model.EventTypeBillingInformationCreated,
+		"schema_version": float64(model.EventSchemaVersion),
+		"user_id":        "user-1",
+		"period_type":    model.PeriodTypeMonth,
+		"from":           "2025-02-01T00:00:00Z",
+		"to":             "2025-03-01T00:00:00Z",
+		"created_at":     "2025-03-02T00:00:00Z",
+		"run_id":         "run-1",
+		"currency":       "EUR",
+		"hash":           "hash-1",
+	} {
+		if fields[key] != expected {
+			t.Errorf("%v: expected %#v, got %#v", key, expected, fields[key])
+		}
+	}
+	if _, ok := fields["time"].(string); !ok {
+		t.Errorf("expected event time, got %#v", fields["time"])
+	}
+	event := model.BillingInformationCreatedEvent{}
+	err = json.Unmarshal(msg.Value, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Tree["analytics"].Month.Cpu != 1 {
+		t.Errorf("unexpected tree %#v", event.Tree)
+	}
+}
+
+func TestBillingRunCompletedMessage(t *testing.T) {
+	msg, err := message("", billingRunCompletedEvent(model.BillingRunCompletedEvent{RunId: "run-1", Periods: 2, Users: 3, Trees: 5, Unchanged: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Key != nil {
+		t.Errorf("expected message without key, got %q", msg.Key)
+	}
+	event := model.BillingRunCompletedEvent{}
+	err = json.Unmarshal(msg.Value, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != model.EventTypeBillingRunCompleted || event.SchemaVersion != model.EventSchemaVersion || event.RunId != "run-1" || event.Periods != 2 || event.Users != 3 || event.Trees != 5 || event.Unchanged != 1 {
+		t.Errorf("unexpected event %#v", event)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/SENERGY-Platform/billing/pkg/model"
+)
+
+// Memory keeps published events in memory and is meant to be used in tests.
+type Memory struct {
+	mux                       sync.Mutex
+	billingInformationCreated []model.BillingInformationCreatedEvent
+	billingRunCompleted       []model.BillingRunCompletedEvent
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (this *Memory) PublishBillingInformationCreated(_ context.Context, info model.BillingInformation) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.billingInformationCreated = append(this.billingInformationCreated, billingInformationCreatedEvent(info))
+	return nil
+}
+
+func (this *Memory) PublishBillingRunCompleted(_ context.Context, event model.BillingRunCompletedEvent) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.billingRunCompleted = append(this.billingRunCompleted, billingRunCompletedEvent(event))
+	return nil
+}
+
+func (this *Memory) BillingInformationCreated() []model.BillingInformationCreatedEvent {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return slices.Clone(this.billingInformationCreated)
+}
+
+func (this *Memory) BillingRunCompleted() []model.BillingRunCompletedEvent {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return slices.Clone(this.billingRunCompleted)
+}
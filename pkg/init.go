@@ -18,11 +18,21 @@ package pkg
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
 
 	"github.com/SENERGY-Platform/billing/pkg/api"
+	"github.com/SENERGY-Platform/billing/pkg/cli"
 	"github.com/SENERGY-Platform/billing/pkg/configuration"
 	"github.com/SENERGY-Platform/billing/pkg/controller"
 	"github.com/SENERGY-Platform/billing/pkg/database"
+	"github.com/SENERGY-Platform/billing/pkg/events"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/billing/pkg/metrics"
+	"github.com/SENERGY-Platform/billing/pkg/model"
+	"github.com/SENERGY-Platform/billing/pkg/tracing"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
 
 	"sync"
 )
@@ -30,22 +40,78 @@ import (
 func Start(ctx context.Context, cancel context.CancelFunc, config configuration.Config, fatal func(err error)) (wg *sync.WaitGroup, err error) {
 	wg = &sync.WaitGroup{}
 
+	err = tracing.Init(ctx, config, wg)
+	if err != nil {
+		return wg, err
+	}
+
 	db, err := database.New(config, ctx, wg)
 	if err != nil {
 		return wg, err
 	}
 
-	ctrl := controller.NewController(ctx, config, fatal, db)
+	publisher, err := events.New(ctx, config, wg)
+	if err != nil {
+		return wg, err
+	}
+
+	m := metrics.New()
+
+	ctrl, err := controller.NewController(ctx, wg, config, fatal, db, publisher, m)
+	if err != nil {
+		return wg, err
+	}
+
+	err = ctrl.MigrateOnStartup(ctx)
+	if err != nil {
+		return wg, err
+	}
 
 	if config.Job {
-		err = ctrl.StoreMonthlyBillingInformation(ctx, config.JobMonths)
+		periods, err := ctrl.JobPeriods(time.Now())
+		if err != nil {
+			return wg, err
+		}
+		if config.JobDryRun {
+			err = dryRun(ctx, ctrl, periods)
+		} else {
+			// set job_run_id to a value stable across retries of the same job, e.g. the name of the kubernetes job
+			runCtx := ctx
+			if config.JobRunId != "" {
+				runCtx = ctrl.WithRunId(ctx, config.JobRunId)
+			}
+			err = ctrl.RunLocked(runCtx, func(ctx context.Context) error {
+				err := ctrl.StoreBillingInformation(ctx, periods)
+				if err != nil || !ctrl.RetentionEnabled() {
+					return err
+				}
+				_, err = ctrl.ApplyRetention(ctx, false)
+				return err
+			})
+			if errors.Is(err, controller.ErrJobLocked) {
+				log.Logger.Info("billing job is already running in another instance, skipping this run")
+				err = nil
+			}
+		}
+		if config.PrometheusPushgatewayUrl != "" {
+			pushErr := m.Push(config.PrometheusPushgatewayUrl, "billing")
+			if pushErr != nil {
+				log.Logger.Error("unable to push metrics", attributes.ErrorKey, pushErr)
+			}
+		}
 		if err != nil {
 			return wg, err
 		}
 	}
 
 	if config.Server {
-		err = api.Start(ctx, wg, config, ctrl)
+		if ctrl.RetentionEnabled() && config.RetentionInterval != "" {
+			err = ctrl.StartRetentionSchedule(ctx, wg)
+			if err != nil {
+				return wg, err
+			}
+		}
+		err = api.Start(ctx, wg, config, ctrl, m)
 		if err != nil {
 			return wg, err
 		}
@@ -55,3 +121,63 @@ func Start(ctx context.Context, cancel context.CancelFunc, config configuration.
 
 	return
 }
+
+// dryRun logs the difference between the fetched trees and the stored snapshots of every user and period.
+func dryRun(ctx context.Context, ctrl *controller.Controller, periods []model.Period) error {
+	report, err := ctrl.DryRunBillingInformation(ctx, "", periods)
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, entry := range report.Entries {
+		if entry.Delta != 0 || entry.StoredAt == nil {
+			changed++
+		}
+		log.Logger.Info("dry run", "user_id", entry.UserId, "period_type", entry.PeriodType, "from", entry.From.Format(time.RFC3339), "to", entry.To.Format(time.RFC3339), "stored", entry.StoredAt != nil, "old_total", entry.OldTotal, "new_total", entry.NewTotal, "delta", entry.Delta)
+	}
+	log.Logger.Info("dry run completed", "entries", len(report.Entries), "changed", changed, "duration", report.CompletedAt.Sub(report.StartedAt).String())
+	return nil
+}
+
+// RunCommand sets up the same database, publisher and controller as Start and runs the cli subcommand in args.
+// Unknown commands are rejected before anything is set up. All components are shut down before it returns.
+// Other than Start, it ignores migrate_on_startup, only the migrate command applies migrations.
+func RunCommand(ctx context.Context, config configuration.Config, args []string, out io.Writer) (err error) {
+	command, err := cli.Lookup(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	wg := &sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	err = tracing.Init(ctx, config, wg)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.New(config, ctx, wg)
+	if err != nil {
+		return err
+	}
+
+	publisher, err := events.New(ctx, config, wg)
+	if err != nil {
+		return err
+	}
+
+	fatal := func(err error) {
+		log.Logger.Error("fatal error in command", attributes.ErrorKey, err)
+		cancel()
+	}
+	ctrl, err := controller.NewController(ctx, wg, config, fatal, db, publisher, metrics.New())
+	if err != nil {
+		return err
+	}
+
+	return command.Run(ctx, ctrl, args[1:], out)
+}
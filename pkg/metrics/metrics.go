@@ -0,0 +1,171 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HttpRequests        *prometheus.CounterVec
+	HttpRequestDuration *prometheus.HistogramVec
+
+	UsersProcessed     prometheus.Counter
+	TreesStored        prometheus.Counter
+	TreesUnchanged     prometheus.Counter
+	CalculatorLatency  prometheus.Histogram
+	CalculatorErrors   prometheus.Counter
+	KeycloakErrors     prometheus.Counter
+	RunDuration        prometheus.Histogram
+	LastSuccessfulRuns *prometheus.GaugeVec
+	// LastBilledPeriodEnds holds the end of the newest billed period per period type, set by BilledPeriod.
+	// Alerting on time() minus it detects a billing job which stopped billing new periods.
+	LastBilledPeriodEnds *prometheus.GaugeVec
+
+	billedPeriodEndsMux sync.Mutex
+	billedPeriodEnds    map[string]time.Time
+
+	SnapshotsRemoved *prometheus.CounterVec
+	LastRetentionRun prometheus.Gauge
+}
+
+func New() *Metrics {
+	reg := prometheus.NewRegistry()
+	m := &Metrics{
+		registry:         reg,
+		billedPeriodEnds: map[string]time.Time{},
+		HttpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "billing_http_requests_total",
+			Help: "number of handled http requests",
+		}, []string{"method", "route", "status"}),
+		HttpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "billing_http_request_duration_seconds",
+			Help:    "latency of handled http requests",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		UsersProcessed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "billing_job_users_processed_total",
+			Help: "number of users processed by the billing job",
+		}),
+		TreesStored: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "billing_job_trees_stored_total",
+			Help: "number of cost trees stored by the billing job",
+		}),
+		TreesUnchanged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "billing_job_trees_unchanged_total",
+			Help: "number of cost trees not stored by the billing job, because they equal the latest stored snapshot",
+		}),
+		CalculatorLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "billing_job_calculator_request_duration_seconds",
+			Help:    "latency of cost calculator requests",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}),
+		CalculatorErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "billing_job_calculator_errors_total",
+			Help: "number of failed cost calculator requests",
+		}),
+		KeycloakErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "billing_job_keycloak_errors_total",
+			Help: "number of failed keycloak requests",
+		}),
+		RunDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "billing_job_run_duration_seconds",
+			Help:    "duration of billing job runs",
+			Buckets: []float64{10, 30, 60, 300, 600, 1800, 3600, 7200},
+		}),
+		LastSuccessfulRuns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "billing_job_last_success_timestamp_seconds",
+			Help: "unix time of the last successful billing job run per period type",
+		}, []string{"period_type"}),
+		LastBilledPeriodEnds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "billing_job_last_billed_period_end_timestamp_seconds",
+			Help: "unix time of the end of the newest period billed by a successful billing job run per period type",
+		}, []string{"period_type"}),
+		SnapshotsRemoved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "billing_retention_snapshots_removed_total",
+			Help: "number of snapshots removed by the retention policy, by mode (delete or archive)",
+		}, []string{"mode"}),
+		LastRetentionRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "billing_retention_last_success_timestamp_seconds",
+			Help: "unix time of the last successful run of the retention policy",
+		}),
+	}
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HttpRequests,
+		m.HttpRequestDuration,
+		m.UsersProcessed,
+		m.TreesStored,
+		m.TreesUnchanged,
+		m.CalculatorLatency,
+		m.CalculatorErrors,
+		m.KeycloakErrors,
+		m.RunDuration,
+		m.LastSuccessfulRuns,
+		m.LastBilledPeriodEnds,
+		m.SnapshotsRemoved,
+		m.LastRetentionRun,
+	)
+	return m
+}
+
+// BilledPeriod records the end of a period billed by a successful run. The gauge of the period type only moves forward,
+// so backfilling older periods does not hide that new periods are missing.
+func (this *Metrics) BilledPeriod(periodType string, end time.Time) {
+	this.billedPeriodEndsMux.Lock()
+	defer this.billedPeriodEndsMux.Unlock()
+	if !end.After(this.billedPeriodEnds[periodType]) {
+		return
+	}
+	this.billedPeriodEnds[periodType] = end
+	this.LastBilledPeriodEnds.WithLabelValues(periodType).Set(float64(end.Unix()))
+}
+
+func (this *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(this.registry, promhttp.HandlerOpts{Registry: this.registry})
+}
+
+// GinMiddleware counts requests and their latency per route template, so path parameters do not create new series.
+func (this *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		this.HttpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		this.HttpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Push sends all metrics to a prometheus pushgateway; used by the billing job, which does not live long enough to be scraped.
+func (this *Metrics) Push(url string, job string) error {
+	return push.New(url, job).Gatherer(this.registry).Push()
+}
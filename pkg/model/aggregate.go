@@ -0,0 +1,35 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// BillingAggregate is the sum of the latest monthly snapshots with From in [From, To).
+// An empty UserId marks an aggregate over all users.
+type BillingAggregate struct {
+	UserId     string         `json:"user_id,omitempty"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Months     []time.Time    `json:"months"`
+	ComputedAt time.Time      `json:"computed_at"`
+	Currency   string         `json:"currency"` // ISO 4217 code of the amounts in Tree
+	Tree       model.CostTree `json:"tree"`
+}
@@ -23,9 +23,104 @@ import (
 )
 
 type BillingInformation struct {
-	From      time.Time      `json:"from"`
-	To        time.Time      `json:"to"`
-	CreatedAt time.Time      `json:"created_at"`
-	UserId    string         `json:"-"`
-	Tree      model.CostTree `json:"tree"`
+	PeriodType PeriodType `json:"period_type"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RunId      string     `json:"run_id,omitempty"` // id of the run which stored the snapshot, empty for snapshots stored before run ids were introduced
+	TimeZone   string     `json:"time_zone"`
+	Hash       string     `json:"hash,omitempty"` // TreeHash of Tree
+	Currency   string     `json:"currency"`       // ISO 4217 code of the amounts in Tree
+	// version of the stored document format, see database migrations
+	SchemaVersion int            `json:"-"`
+	UserId        string         `json:"-"`
+	Tree          model.CostTree `json:"tree"`
+}
+
+// IsFinal reports if the snapshot was created after its period ended, so it will not change anymore.
+func (this BillingInformation) IsFinal() bool {
+	return !this.CreatedAt.Before(this.To)
+}
+
+// TreeHash returns the stored hash of the tree, or computes it for snapshots stored without one.
+func (this BillingInformation) TreeHash() (string, error) {
+	if this.Hash != "" {
+		return this.Hash, nil
+	}
+	return TreeHash(this.Tree)
+}
+
+// UserBillingInformation is BillingInformation with the user id, for listings over several users.
+type UserBillingInformation struct {
+	UserId string `json:"user_id"`
+	BillingInformation
+}
+
+// BillingPeriodSummary describes the stored snapshots of a single period without their trees.
+type BillingPeriodSummary struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Snapshots       int       `json:"snapshots"`
+	LatestCreatedAt time.Time `json:"latest_created_at"`
+}
+
+// BillingMonthSummary is an entry of the month listing, with the total of the latest snapshot of the month.
+type BillingMonthSummary struct {
+	Month           string    `json:"month"` // YYYY-MM in the billing time zone
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Total           float64   `json:"total"`
+	Currency        string    `json:"currency"` // ISO 4217 code of Total
+	Snapshots       int       `json:"snapshots"`
+	LatestCreatedAt time.Time `json:"latest_created_at"`
+}
+
+// Snapshot identifies a stored BillingInformation without its tree.
+type Snapshot struct {
+	UserId     string     `json:"user_id"`
+	PeriodType PeriodType `json:"period_type"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RunId      string     `json:"run_id,omitempty"`
+	Hash       string     `json:"hash"`
+}
+
+// IsFinal reports if the snapshot was created after its period ended, see BillingInformation.IsFinal.
+func (this Snapshot) IsFinal() bool {
+	return !this.CreatedAt.Before(this.To)
+}
+
+// Snapshot returns the snapshot info without the tree.
+func (this BillingInformation) Snapshot() (Snapshot, error) {
+	hash, err := this.TreeHash()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{
+		UserId:     this.UserId,
+		PeriodType: this.PeriodType,
+		From:       this.From,
+		To:         this.To,
+		CreatedAt:  this.CreatedAt,
+		RunId:      this.RunId,
+		Hash:       hash,
+	}, nil
+}
+
+// BillingChange describes a user whose bill differs between two snapshots of the same period.
+// Old is nil if the user had no snapshot at the first instant, New is nil if the snapshot was removed.
+type BillingChange struct {
+	UserId string    `json:"user_id"`
+	Old    *Snapshot `json:"old"`
+	New    *Snapshot `json:"new"`
+}
+
+// BillingChangeReport lists the users whose bill for the period changed between the snapshots valid at Since and Until.
+type BillingChangeReport struct {
+	PeriodType PeriodType      `json:"period_type"`
+	From       time.Time       `json:"from"`
+	Since      time.Time       `json:"since"`
+	Until      time.Time       `json:"until"`
+	Changes    []BillingChange `json:"changes"`
 }
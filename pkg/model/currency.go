@@ -0,0 +1,147 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"cmp"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency reports if currency looks like an ISO 4217 code.
+func ValidCurrency(currency string) bool {
+	return currencyPattern.MatchString(currency)
+}
+
+// ExchangeRate converts amounts in the currency From to the currency To by multiplication with Rate.
+// It is valid from ValidFrom until the ValidFrom of the next rate of the same currencies.
+type ExchangeRate struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ValidFrom time.Time `json:"valid_from"`
+	Rate      float64   `json:"rate"`
+}
+
+// Validate checks the currencies and the rate.
+func (this ExchangeRate) Validate() error {
+	if !ValidCurrency(this.From) || !ValidCurrency(this.To) {
+		return errors.New("expected currencies as ISO 4217 codes like EUR")
+	}
+	if this.From == this.To {
+		return errors.New("exchange rate has to convert between different currencies")
+	}
+	if this.ValidFrom.IsZero() {
+		return errors.New("missing valid_from")
+	}
+	if this.Rate <= 0 {
+		return errors.New("rate has to be positive")
+	}
+	return nil
+}
+
+// ExchangeRates is the table of all known exchange rates.
+type ExchangeRates []ExchangeRate
+
+// RateAt returns the factor converting amounts in currency from to currency to, using the latest rate valid at the given instant.
+// If only rates from to to from are known, the inverse of such a rate is used. found is false if no rate is valid at the instant.
+func (this ExchangeRates) RateAt(from string, to string, at time.Time) (rate float64, found bool) {
+	if from == to {
+		return 1, true
+	}
+	var latest *ExchangeRate
+	for _, candidate := range this {
+		if candidate.ValidFrom.After(at) {
+			continue
+		}
+		direct := candidate.From == from && candidate.To == to
+		inverse := candidate.From == to && candidate.To == from
+		if !direct && !inverse {
+			continue
+		}
+		// a direct rate is preferred over an inverse rate of the same date
+		if latest == nil || candidate.ValidFrom.After(latest.ValidFrom) || (candidate.ValidFrom.Equal(latest.ValidFrom) && direct) {
+			latest = &candidate
+		}
+	}
+	if latest == nil {
+		return 0, false
+	}
+	if latest.From == from {
+		return latest.Rate, true
+	}
+	return 1 / latest.Rate, true
+}
+
+// ConvertBillingInformation converts the tree into currency with the rate valid at the start of the billing period.
+// The hash is replaced by the hash of the converted tree. Snapshots without currency are treated as stored in defaultCurrency.
+func (this ExchangeRates) ConvertBillingInformation(info BillingInformation, currency string, defaultCurrency string) (BillingInformation, error) {
+	if info.Currency == "" {
+		info.Currency = defaultCurrency
+	}
+	if currency == "" || currency == info.Currency {
+		return info, nil
+	}
+	rate, found := this.RateAt(info.Currency, currency, info.From)
+	if !found {
+		return info, errors.Join(ErrBadRequest, errors.New("no exchange rate from "+info.Currency+" to "+currency+" valid at "+info.From.Format(time.DateOnly)))
+	}
+	info.Tree = ConvertTree(info.Tree, rate)
+	info.Currency = currency
+	hash, err := TreeHash(info.Tree)
+	if err != nil {
+		return info, err
+	}
+	info.Hash = hash
+	return info, nil
+}
+
+// ConvertTree returns a copy of the tree with every cost entry multiplied by rate.
+func ConvertTree(tree model.CostTree, rate float64) model.CostTree {
+	result := model.CostTree{}
+	for name, node := range tree {
+		node.Month = convertEntry(node.Month, rate)
+		node.EstimationMonth = convertEntry(node.EstimationMonth, rate)
+		if node.Children != nil {
+			node.Children = ConvertTree(node.Children, rate)
+		}
+		result[name] = node
+	}
+	return result
+}
+
+func convertEntry(entry model.CostEntry, rate float64) model.CostEntry {
+	return model.CostEntry{
+		Cpu:      entry.Cpu * rate,
+		Ram:      entry.Ram * rate,
+		Storage:  entry.Storage * rate,
+		Requests: entry.Requests * rate,
+	}
+}
+
+// SortExchangeRates sorts by currencies and validity.
+func SortExchangeRates(rates ExchangeRates) {
+	slices.SortFunc(rates, func(a, b ExchangeRate) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To), a.ValidFrom.Compare(b.ValidFrom))
+	})
+}
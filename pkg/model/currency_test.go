@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestExchangeRatesRateAt(t *testing.T) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rates := ExchangeRates{
+		{From: "EUR", To: "CHF", ValidFrom: january, Rate: 0.9},
+		{From: "EUR", To: "CHF", ValidFrom: march, Rate: 0.8},
+		{From: "USD", To: "EUR", ValidFrom: january, Rate: 0.5},
+	}
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		at       time.Time
+		rate     float64
+		notFound bool
+	}{
+		{name: "same currency", from: "EUR", to: "EUR", at: january, rate: 1},
+		{name: "first rate", from: "EUR", to: "CHF", at: march.Add(-time.Second), rate: 0.9},
+		{name: "latest rate", from: "EUR", to: "CHF", at: march, rate: 0.8},
+		{name: "inverse rate", from: "EUR", to: "USD", at: march, rate: 2},
+		{name: "before first rate", from: "EUR", to: "CHF", at: january.Add(-time.Second), notFound: true},
+		{name: "unknown currency", from: "EUR", to: "GBP", at: march, notFound: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate, found := rates.RateAt(test.from, test.to, test.at)
+			if found == test.notFound {
+				t.Fatalf("expected found %v, got %v", !test.notFound, found)
+			}
+			if rate != test.rate {
+				t.Errorf("expected rate %v, got %v", test.rate, rate)
+			}
+		})
+	}
+}
+
+func TestConvertBillingInformation(t *testing.T) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rates := ExchangeRates{{From: "EUR", To: "CHF", ValidFrom: january, Rate: 0.5}}
+	info := BillingInformation{From: january, Tree: model.CostTree{"analytics": node(4, map[string]model.CostWithChildren{"pipeline-a": node(2, nil)})}}
+	info.Hash = treeHash(t, info.Tree)
+
+	converted, err := rates.ConvertBillingInformation(info, "CHF", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Currency != "CHF" || converted.Tree["analytics"].Month.Cpu != 2 || converted.Tree["analytics"].Children["pipeline-a"].Month.Cpu != 1 {
+		t.Errorf("unexpected conversion %#v", converted)
+	}
+	if converted.Hash != treeHash(t, converted.Tree) {
+		t.Error("expected hash of the converted tree")
+	}
+	if info.Tree["analytics"].Month.Cpu != 4 {
+		t.Error("conversion changed the original tree")
+	}
+
+	unchanged, err := rates.ConvertBillingInformation(info, "", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Currency != "EUR" || unchanged.Hash != info.Hash {
+		t.Errorf("expected snapshot in the default currency, got %#v", unchanged)
+	}
+
+	_, err = rates.ConvertBillingInformation(info, "USD", "EUR")
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("expected bad request for missing rate, got %v", err)
+	}
+}
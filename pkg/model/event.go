@@ -0,0 +1,61 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// EventSchemaVersion is increased on every change of the published event structs.
+// Version 2 added the currency and hash of created billing information.
+const EventSchemaVersion = 2
+
+const EventTypeBillingInformationCreated = "billing-information-created"
+const EventTypeBillingRunCompleted = "billing-run-completed"
+
+type EventHeader struct {
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	Time          time.Time `json:"time"`
+}
+
+type BillingInformationCreatedEvent struct {
+	EventHeader
+	UserId     string         `json:"user_id"`
+	PeriodType PeriodType     `json:"period_type"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	CreatedAt  time.Time      `json:"created_at"`
+	RunId      string         `json:"run_id"`
+	Currency   string         `json:"currency"` // ISO 4217 code of the amounts in Tree
+	Hash       string         `json:"hash"`     // TreeHash of Tree
+	Tree       model.CostTree `json:"tree"`
+}
+
+type BillingRunCompletedEvent struct {
+	EventHeader
+	RunId       string    `json:"run_id"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Months      int       `json:"months"`
+	Periods     int       `json:"periods"`
+	Users       int       `json:"users"`
+	Trees       int       `json:"trees"`
+	Unchanged   int       `json:"unchanged"` // trees not stored, because they equal the latest stored snapshot
+}
@@ -0,0 +1,33 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+type HealthStatus = string
+
+const HealthStatusUp HealthStatus = "up"
+const HealthStatusDown HealthStatus = "down"
+
+type HealthReport struct {
+	Status       HealthStatus                `json:"status"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+type DependencyHealth struct {
+	Status    HealthStatus `json:"status"`
+	LatencyMs int64        `json:"latency_ms"`
+	Error     string       `json:"error,omitempty"`
+}
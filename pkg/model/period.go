@@ -0,0 +1,41 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+type PeriodType = string
+
+const PeriodTypeMonth PeriodType = "month"
+const PeriodTypeQuarter PeriodType = "quarter"
+const PeriodTypeWeek PeriodType = "week"
+const PeriodTypeCustom PeriodType = "custom"
+
+var PeriodTypes = []PeriodType{PeriodTypeMonth, PeriodTypeQuarter, PeriodTypeWeek, PeriodTypeCustom}
+
+func IsValidPeriodType(periodType PeriodType) bool {
+	return slices.Contains(PeriodTypes, periodType)
+}
+
+type Period struct {
+	Type PeriodType `json:"type"`
+	From time.Time  `json:"from"`
+	To   time.Time  `json:"to"`
+}
@@ -0,0 +1,30 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// RetentionReport summarizes a run of the retention policy.
+type RetentionReport struct {
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Mode        string    `json:"mode"`
+	DryRun      bool      `json:"dry_run"`
+	Periods     int       `json:"periods"` // number of checked periods of all users
+	Kept        int       `json:"kept"`
+	Removed     int       `json:"removed"` // with DryRun the number of snapshots which would have been removed
+}
@@ -0,0 +1,71 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// BillingRunRequest triggers a run of the billing job.
+// Without From and To, the periods configured for the billing job are used.
+type BillingRunRequest struct {
+	PeriodType PeriodType `json:"period_type,omitempty"`
+	From       string     `json:"from,omitempty"` // YYYY-MM-DD
+	To         string     `json:"to,omitempty"`   // YYYY-MM-DD
+	UserId     string     `json:"user_id,omitempty"`
+	RunId      string     `json:"run_id,omitempty"` // retrying a run with the same id replaces its snapshots instead of adding new ones
+	DryRun     bool       `json:"dry_run"`
+}
+
+// DryRunReport compares the trees fetched in a dry run with the latest stored snapshots.
+type DryRunReport struct {
+	StartedAt   time.Time     `json:"started_at"`
+	CompletedAt time.Time     `json:"completed_at"`
+	Entries     []DryRunEntry `json:"entries"`
+}
+
+type DryRunEntry struct {
+	UserId     string     `json:"user_id"`
+	PeriodType PeriodType `json:"period_type"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	StoredAt   *time.Time `json:"stored_at,omitempty"` // creation time of the compared snapshot, nil if nothing is stored yet
+	OldTotal   float64    `json:"old_total"`
+	NewTotal   float64    `json:"new_total"`
+	Delta      float64    `json:"delta"`
+}
+
+// BackfillProgress is reported after every period of a backfill.
+type BackfillProgress struct {
+	Period    Period `json:"period"`
+	Index     int    `json:"index"` // 1 based position of Period in the backfill
+	Total     int    `json:"total"`
+	Stored    int    `json:"stored"`    // users with a new snapshot of Period
+	Skipped   int    `json:"skipped"`   // users already having a final snapshot of Period
+	Unchanged int    `json:"unchanged"` // users whose fetched tree equals their latest stored snapshot
+}
+
+// BillingGap is a period without any stored snapshot of a user who existed during the period.
+type BillingGap struct {
+	UserId string `json:"user_id"`
+	Period Period `json:"period"`
+}
+
+type GapReport struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Users int          `json:"users"`
+	Gaps  []BillingGap `json:"gaps"`
+}
@@ -0,0 +1,169 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// AddTree adds the cost entries of every node in src to the node with the same path in dst.
+// Nodes missing in dst are created.
+func AddTree(dst model.CostTree, src model.CostTree) {
+	for name, node := range src {
+		sum := dst[name]
+		sum.CostWithEstimation.Add(node.CostWithEstimation)
+		if len(node.Children) > 0 {
+			if sum.Children == nil {
+				sum.Children = map[string]model.CostWithChildren{}
+			}
+			AddTree(sum.Children, node.Children)
+		}
+		dst[name] = sum
+	}
+}
+
+// EntryTotal returns the sum of all cost types of the entry.
+func EntryTotal(entry model.CostEntry) float64 {
+	return entry.Cpu + entry.Ram + entry.Storage + entry.Requests
+}
+
+// TreeTotal returns the sum of the monthly costs of the top level nodes of the tree.
+func TreeTotal(tree model.CostTree) (total float64) {
+	for _, node := range tree {
+		total += EntryTotal(node.Month)
+	}
+	return total
+}
+
+// TreeHash returns a hex encoded sha256 hash of the canonical json encoding of the tree.
+// encoding/json writes map keys sorted, so equal trees have equal hashes independent of map order.
+// It fails for trees json can not encode, like trees with NaN or infinite costs.
+func TreeHash(tree model.CostTree) (string, error) {
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return "", errors.Join(errors.New("unable to hash tree"), err)
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// BillingInformationETag returns a strong entity tag for the snapshots, derived from their creation times and tree hashes.
+func BillingInformationETag(infos []BillingInformation) (string, error) {
+	hash := sha256.New()
+	for _, info := range infos {
+		treeHash, err := info.TreeHash()
+		if err != nil {
+			return "", err
+		}
+		hash.Write([]byte(info.CreatedAt.UTC().Format(time.RFC3339Nano)))
+		hash.Write([]byte(info.RunId))
+		hash.Write([]byte(info.TimeZone))
+		hash.Write([]byte(treeHash))
+		hash.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+// TreeFilter selects a part of a CostTree.
+type TreeFilter struct {
+	// Path selects the node with these names from the root, see SubTree. Empty selects the whole tree.
+	Path []string
+	// Depth is the number of levels kept, counted from the node at Path or from the top level nodes without Path.
+	// Zero keeps all levels, see CutTree.
+	Depth int
+}
+
+// IsEmpty reports if the filter keeps the whole tree.
+func (this TreeFilter) IsEmpty() bool {
+	return len(this.Path) == 0 && this.Depth <= 0
+}
+
+// Apply returns the filtered tree. It is empty if the path does not exist in the tree.
+func (this TreeFilter) Apply(tree model.CostTree) model.CostTree {
+	result, _ := SubTree(tree, this.Path)
+	if this.Depth > 0 {
+		result = CutTree(result, this.Depth+max(len(this.Path)-1, 0))
+	}
+	return result
+}
+
+// SubTree returns a tree with the node at path and its ancestors. Ancestors keep their costs, but no other children.
+// found is false and the result empty if there is no node at path.
+func SubTree(tree model.CostTree, path []string) (result model.CostTree, found bool) {
+	if len(path) == 0 {
+		return tree, true
+	}
+	node, ok := tree[path[0]]
+	if !ok {
+		return model.CostTree{}, false
+	}
+	if len(path) > 1 {
+		children, ok := SubTree(node.Children, path[1:])
+		if !ok {
+			return model.CostTree{}, false
+		}
+		node.Children = children
+	}
+	return model.CostTree{path[0]: node}, true
+}
+
+// CutTree returns a copy of the tree without the nodes below the given number of levels, depth 1 keeps only the top level nodes.
+// The calculator includes the costs of children in their parents. For nodes whose own entry is lower than the sum of their
+// removed children, e.g. nodes without costs of their own, the sum is used per cost type, so no costs are lost by the cut.
+func CutTree(tree model.CostTree, depth int) model.CostTree {
+	result := model.CostTree{}
+	for name, node := range tree {
+		if depth <= 1 {
+			node.CostWithEstimation = rollUp(node)
+			node.Children = nil
+		} else if len(node.Children) > 0 {
+			node.Children = CutTree(node.Children, depth-1)
+		}
+		result[name] = node
+	}
+	return result
+}
+
+// rollUp returns the costs of the node, raised per cost type to the sum of the rolled up costs of its children.
+func rollUp(node model.CostWithChildren) model.CostWithEstimation {
+	if len(node.Children) == 0 {
+		return node.CostWithEstimation
+	}
+	sum := model.CostWithEstimation{}
+	for _, child := range node.Children {
+		sum.Add(rollUp(child))
+	}
+	return model.CostWithEstimation{
+		Month:           maxEntry(node.Month, sum.Month),
+		EstimationMonth: maxEntry(node.EstimationMonth, sum.EstimationMonth),
+	}
+}
+
+func maxEntry(a model.CostEntry, b model.CostEntry) model.CostEntry {
+	return model.CostEntry{
+		Cpu:      max(a.Cpu, b.Cpu),
+		Ram:      max(a.Ram, b.Ram),
+		Storage:  max(a.Storage, b.Storage),
+		Requests: max(a.Requests, b.Requests),
+	}
+}
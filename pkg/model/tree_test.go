@@ -0,0 +1,158 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func node(cpu float64, children map[string]model.CostWithChildren) model.CostWithChildren {
+	return model.CostWithChildren{
+		CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Cpu: cpu}},
+		Children:           children,
+	}
+}
+
+func TestAddTree(t *testing.T) {
+	dst := model.CostTree{
+		"analytics": node(3, map[string]model.CostWithChildren{
+			"pipeline-a": node(1, nil),
+			"pipeline-b": node(2, nil),
+		}),
+	}
+	AddTree(dst, model.CostTree{
+		"analytics": node(5, map[string]model.CostWithChildren{
+			"pipeline-b": node(2, nil),
+			"pipeline-c": node(3, nil),
+		}),
+		"imports": node(4, map[string]model.CostWithChildren{
+			"import-a": node(4, nil),
+		}),
+	})
+	expected := model.CostTree{
+		"analytics": node(8, map[string]model.CostWithChildren{
+			"pipeline-a": node(1, nil),
+			"pipeline-b": node(4, nil),
+			"pipeline-c": node(3, nil),
+		}),
+		"imports": node(4, map[string]model.CostWithChildren{
+			"import-a": node(4, nil),
+		}),
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("expected %#v, got %#v", expected, dst)
+	}
+}
+
+// treeHash returns the hash of the tree, failing the test if it can not be computed.
+func treeHash(t *testing.T, tree model.CostTree) string {
+	t.Helper()
+	hash, err := TreeHash(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestTreeHash(t *testing.T) {
+	a := model.CostTree{
+		"analytics": node(3, map[string]model.CostWithChildren{"pipeline-a": node(1, nil), "pipeline-b": node(2, nil)}),
+		"imports":   node(4, nil),
+	}
+	b := model.CostTree{}
+	b["imports"] = node(4, map[string]model.CostWithChildren{})
+	b["analytics"] = node(3, map[string]model.CostWithChildren{"pipeline-b": node(2, nil), "pipeline-a": node(1, nil)})
+	if treeHash(t, a) != treeHash(t, b) {
+		t.Error("expected equal trees to have equal hashes")
+	}
+	b["imports"] = node(5, nil)
+	if treeHash(t, a) == treeHash(t, b) {
+		t.Error("expected different trees to have different hashes")
+	}
+	if _, err := TreeHash(model.CostTree{"imports": node(math.NaN(), nil)}); err == nil {
+		t.Error("expected error for a tree with NaN costs")
+	}
+}
+
+func TestBillingInformationETag(t *testing.T) {
+	createdAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	infos := []BillingInformation{{CreatedAt: createdAt, Tree: model.CostTree{"a": node(1, nil)}}}
+	etag := func(infos []BillingInformation) string {
+		t.Helper()
+		etag, err := BillingInformationETag(infos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return etag
+	}
+	first := etag(infos)
+	if first != etag([]BillingInformation{{CreatedAt: createdAt, Hash: treeHash(t, infos[0].Tree)}}) {
+		t.Error("stored and computed hash should result in the same etag")
+	}
+	infos[0].Tree["a"] = node(2, nil)
+	if first == etag(infos) {
+		t.Error("changed tree should change the etag")
+	}
+	if first == etag(nil) {
+		t.Error("missing snapshots should change the etag")
+	}
+	infos[0].Tree["a"] = node(math.Inf(1), nil)
+	if _, err := BillingInformationETag(infos); err == nil {
+		t.Error("expected error for a tree with infinite costs")
+	}
+}
+
+func TestTreeFilter(t *testing.T) {
+	tree := model.CostTree{
+		"analytics": node(3, map[string]model.CostWithChildren{
+			"pipeline-a": node(1, map[string]model.CostWithChildren{"operator": node(1, nil)}),
+			"pipeline-b": node(2, nil),
+		}),
+		"imports": node(0, map[string]model.CostWithChildren{"import-a": node(4, nil)}),
+	}
+	tests := []struct {
+		name     string
+		filter   TreeFilter
+		expected model.CostTree
+	}{
+		{name: "empty", filter: TreeFilter{}, expected: tree},
+		{name: "missing path", filter: TreeFilter{Path: []string{"analytics", "pipeline-c"}}, expected: model.CostTree{}},
+		{name: "path", filter: TreeFilter{Path: []string{"analytics", "pipeline-b"}}, expected: model.CostTree{
+			"analytics": node(3, map[string]model.CostWithChildren{"pipeline-b": node(2, nil)}),
+		}},
+		{name: "depth", filter: TreeFilter{Depth: 1}, expected: model.CostTree{
+			"analytics": node(3, nil),
+			"imports":   node(4, nil),
+		}},
+		{name: "path and depth", filter: TreeFilter{Path: []string{"analytics"}, Depth: 2}, expected: model.CostTree{
+			"analytics": node(3, map[string]model.CostWithChildren{"pipeline-a": node(1, nil), "pipeline-b": node(2, nil)}),
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.filter.Apply(tree)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ *    Copyright 2026 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/SENERGY-Platform/billing/pkg/configuration"
+	"github.com/SENERGY-Platform/billing/pkg/log"
+	"github.com/SENERGY-Platform/go-service-base/struct-logger/attributes"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TracerName = "github.com/SENERGY-Platform/billing"
+
+const ExporterNone = "none"
+const ExporterOtlp = "otlp"
+
+// Init sets the global propagator and, if config.OtelExporter is "otlp", a tracer provider exporting to config.OtelEndpoint.
+// Without exporter the global no-op tracer provider stays in place.
+func Init(ctx context.Context, config configuration.Config, wg *sync.WaitGroup) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	switch config.OtelExporter {
+	case ExporterNone, "":
+		return nil
+	case ExporterOtlp:
+	default:
+		return errors.New("unknown otel_exporter '" + config.OtelExporter + "'")
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.OtelEndpoint))
+	if err != nil {
+		return err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.OtelServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.OtelSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		err := provider.Shutdown(context.Background())
+		if err != nil {
+			log.Logger.Error("unable to shutdown tracer provider", attributes.ErrorKey, err)
+		}
+	}()
+	return nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start starts a span with the package wide tracer.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on the span, if set, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport returns a http.RoundTripper creating client spans and injecting the trace context into outgoing requests.
+func Transport() http.RoundTripper {
+	return otelhttp.NewTransport(http.DefaultTransport)
+}